@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"simulation-modeling/sim"
+	"strings"
+	"time"
+)
+
+// Width of section in animation, characters.
+const sectionWidth = 20
+
+// Waypoints of transaction and time when it moved to them.
+type entry struct {
+	points sim.Points
+	time   float64
+}
+
+// Animation of line in terminal: frame is drawn after each phase of simulation,
+// real time between frames is simulation time divided by speed in minutes per second.
+// Keys: space pauses and resumes, s makes single step, + and - change speed, q stops animation.
+type Animation struct {
+	Speed   float64
+	Output  io.Writer
+	paused  bool
+	stopped bool
+	last    float64
+	entered map[int]entry
+	keys    chan byte
+	raw     bool
+}
+
+func NewAnimation(Speed float64, Output io.Writer) *Animation {
+	A := &Animation{Speed: Speed, Output: Output, entered: make(map[int]entry), keys: make(chan byte, 16)}
+	// Keys are read without Enter if terminal can be switched to cbreak mode.
+	stty := exec.Command("stty", "cbreak", "-echo")
+	stty.Stdin = os.Stdin
+	A.raw = stty.Run() == nil
+	go func() {
+		buffer := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(buffer); err != nil {
+				return
+			} else if n == 1 {
+				A.keys <- buffer[0]
+			}
+		}
+	}()
+	return A
+}
+
+// Close restores terminal mode.
+func (A *Animation) Close() {
+	if !A.raw {
+		return
+	}
+	stty := exec.Command("stty", "-cbreak", "echo")
+	stty.Stdin = os.Stdin
+	stty.Run()
+}
+
+// key handles pressed key, it returns true if simulation should make single step.
+func (A *Animation) key(Key byte) bool {
+	switch Key {
+	case ' ':
+		A.paused = !A.paused
+	case 's':
+		A.paused = true
+		return true
+	case '+':
+		A.Speed *= 2
+	case '-':
+		A.Speed /= 2
+	case 'q':
+		A.stopped = true
+	}
+	return false
+}
+
+// Frame draws state of line and waits for real time of frame or for keys if animation is paused.
+func (A *Animation) Frame(S *sim.Sim, M *Model) {
+	if A.stopped {
+		return
+	}
+	fmt.Fprint(A.Output, "\033[H\033[2J", A.Render(S, M))
+	timer := time.NewTimer(time.Duration((S.GetSimTime() - A.last) / A.Speed * float64(time.Second)))
+	defer timer.Stop()
+	A.last = S.GetSimTime()
+	for !A.stopped {
+		if A.paused {
+			if A.key(<-A.keys) {
+				return
+			}
+			fmt.Fprint(A.Output, "\033[H\033[2J", A.Render(S, M))
+			continue
+		}
+		select {
+		case key := <-A.keys:
+			if A.key(key) {
+				return
+			}
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+// holders returns transactions by their current points, transactions in waitlist are marked.
+func holders(S *sim.Sim) (map[int]*sim.Transaction, map[*sim.Transaction]bool) {
+	result, waiting := make(map[int]*sim.Transaction), make(map[*sim.Transaction]bool)
+	for _, tr := range S.GetFuture() {
+		result[sim.GetPoints(*tr).Current] = tr
+	}
+	for _, tr := range S.GetWaitlist() {
+		result[sim.GetPoints(*tr).Current] = tr
+		waiting[tr] = true
+	}
+	return result, waiting
+}
+
+// arrow returns mark of train by direction of movement.
+func arrow(Direction int) string {
+	if Direction == 1 {
+		return "<"
+	}
+	return ">"
+}
+
+// Render returns diagram of line: trains on sections by their progress, tracks of stations with
+// holding trains and waitlist.
+func (A *Animation) Render(S *sim.Sim, M *Model) string {
+	held, waiting := holders(S)
+	// Train leaving line from terminal doesn't enter sections any more.
+	for _, tr := range S.GetFuture() {
+		if sim.GetPoints(*tr).Next == Point0 {
+			delete(A.entered, sim.GetId(*tr))
+		}
+	}
+	now := S.GetSimTime()
+	var line strings.Builder
+	for i, station := range M.Stations {
+		var tracks []string
+		for _, track := range station.Tracks {
+			mark := "-"
+			if tr, ok := held[track]; ok {
+				_, direction := M.Heading(sim.GetPoints(*tr))
+				mark = fmt.Sprintf("%s%d", arrow(direction), sim.GetId(*tr))
+			}
+			tracks = append(tracks, mark)
+		}
+		fmt.Fprintf(&line, "%s[%s]", station.Name, strings.Join(tracks, "|"))
+		if i == len(M.Sections) {
+			break
+		}
+		cells := []byte(strings.Repeat("*", sectionWidth))
+		for _, point := range M.Sections[i].Points {
+			tr, ok := held[point]
+			if !ok {
+				continue
+			}
+			points, id := sim.GetPoints(*tr), sim.GetId(*tr)
+			if A.entered[id].points != points {
+				A.entered[id] = entry{points, now}
+			}
+			progress := 1.0
+			if end, start := sim.GetTime(*tr), A.entered[id].time; !waiting[tr] && end > start {
+				progress = (now - start) / (end - start)
+			}
+			low, high, _ := M.span(point)
+			direction := 0
+			if next, ok := M.stationIndex(points.Next); ok && next == i {
+				direction = 1
+			} else if d, ok := M.directions[point]; ok {
+				direction = d
+			}
+			position := low + (high-low)*progress
+			if direction == 1 {
+				position = high - (high-low)*progress
+			}
+			cell := int((position - float64(i)) * sectionWidth)
+			if cell >= sectionWidth {
+				cell = sectionWidth - 1
+			}
+			cells[cell] = arrow(direction)[0]
+		}
+		fmt.Fprintf(&line, " %s ", cells)
+	}
+	state := "running"
+	if A.paused {
+		state = "paused"
+	}
+	var list []string
+	for _, tr := range S.GetWaitlist() {
+		place := sim.GetPoints(*tr).Current
+		if place == Point0 {
+			place = sim.GetPoints(*tr).Next
+		}
+		list = append(list, fmt.Sprintf("%d at %s for %.1f", sim.GetId(*tr), PointLabel(M, place), now-sim.GetTime(*tr)))
+	}
+	return fmt.Sprintf("%s %02d:%04.1f, speed %.1f min/s, %s\n\n%s\n\nWaitlist: %s\n\nKeys: space pause, s step, + faster, - slower, q stop animation\n",
+		M.Title, int(now)/60, now-float64(int(now)/60*60), A.Speed, state, line.String(), strings.Join(list, ", "))
+}
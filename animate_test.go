@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnimationKey(t *testing.T) {
+	tests := []struct {
+		key             byte
+		step            bool
+		paused, stopped bool
+		speed           float64
+	}{
+		{' ', false, true, false, 10},
+		{'s', true, true, false, 10},
+		{'+', false, false, false, 20},
+		{'-', false, false, false, 5},
+		{'q', false, false, true, 10},
+		{'x', false, false, false, 10},
+	}
+
+	for _, test := range tests {
+		A := &Animation{Speed: 10, entered: make(map[int]entry)}
+		if step := A.key(test.key); step != test.step || A.paused != test.paused || A.stopped != test.stopped || A.Speed != test.speed {
+			t.Errorf("Expected step %t, paused %t, stopped %t and speed %.0f after key %q, got %t, %+v",
+				test.step, test.paused, test.stopped, test.speed, test.key, step, A)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	M := testModel(t, nil, 6)
+	S := simulate(M, FIFODispatcher{}, 1)
+	A := &Animation{Speed: 10, entered: make(map[int]entry)}
+	A.key(' ')
+
+	frame := A.Render(S, M)
+	for _, part := range []string{M.Title, "A[", "C[", "B[", "paused", "Waitlist:"} {
+		if !strings.Contains(frame, part) {
+			t.Errorf("Expected %q in frame %q", part, frame)
+		}
+	}
+}
+
+func TestRenderEntered(t *testing.T) {
+	M := testModel(t, nil, 24)
+	R := NewStream(1, 0, false)
+	S := Start(R, M, nil)
+	A := &Animation{Speed: 10, entered: make(map[int]entry)}
+
+	// Entries of trains on sections are kept only until trains leave line.
+	for !S.IsFinish() {
+		Phases(S, R, M, FIFODispatcher{})
+		A.Render(S, M)
+		if len(A.entered) > 4 {
+			t.Fatalf("Expected entries only of trains on line, got %d at %.2f", len(A.entered), S.GetSimTime())
+		}
+	}
+}
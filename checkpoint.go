@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"simulation-modeling/sim"
+)
+
+// State of run of planned train in checkpoint, train is index in timetable.
+type RunState struct {
+	Train                int
+	Ready                float64
+	Arrivals, Departures map[int]float64
+}
+
+// State of energy accounting in checkpoint.
+type EnergyState struct {
+	Trains             map[int]*EnergyUse
+	Types              map[int]int
+	Sections, Stations []EnergyUse
+}
+
+// State of fleet in checkpoint.
+type FleetState struct {
+	Crews                         map[int][]float64
+	Departures                    map[int]float64
+	Busy, CrewBusy                float64
+	Departed, Cancelled, Uncrewed map[int]int
+	Units, Due, Held              map[int]bool
+}
+
+// State of passengers in checkpoint.
+type PassengerState struct {
+	Queues                        [][2][]float64
+	Next                          [][2]float64
+	Loads                         map[int]map[int]int
+	Dwelled                       map[int]int
+	Dwells                        [][]float64
+	Boarded, Alighted, LeftBehind int
+	Waiting                       float64
+}
+
+// Checkpoint of simulation run: state of simulator, random stream, dispatcher and layers of model.
+// Run is resumed with the same model and options, it continues exactly as it would without interruption.
+type Checkpoint struct {
+	Seed, Draws int64
+	Antithetic  bool
+	Dispatch    string
+	Last        map[int]int `json:",omitempty"`
+	Sim         sim.Snapshot
+	Runs        map[int]RunState
+	Energy      *EnergyState    `json:",omitempty"`
+	Fleet       *FleetState     `json:",omitempty"`
+	Passengers  *PassengerState `json:",omitempty"`
+	Movements   []Movement      `json:",omitempty"`
+	Ready       map[int]float64
+}
+
+// Checkpoints of simulation run are written to files with prefix after each period in minutes.
+// File name has simulation time of the first phase at or after end of period.
+type Checkpoints struct {
+	Every  float64
+	Prefix string
+	next   float64
+}
+
+// stream returns counting stream of source and whether it's antithetic.
+func stream(R sim.Source) (*sim.Stream, bool) {
+	switch r := R.(type) {
+	case *sim.Stream:
+		return r, false
+	case sim.Antithetic:
+		if s, ok := r.Source.(*sim.Stream); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// NewCheckpoint returns checkpoint of current state of simulation run.
+func NewCheckpoint(S *sim.Sim, R sim.Source, M *Model, D Dispatcher) (*Checkpoint, error) {
+	s, antithetic := stream(R)
+	if s == nil {
+		return nil, errors.New("random source of simulation can't be saved in checkpoint")
+	}
+	C := &Checkpoint{Seed: s.Seed, Draws: s.Draws, Antithetic: antithetic, Dispatch: D.Name(), Sim: S.Snapshot(), Ready: M.ready}
+	if alternate, ok := D.(*AlternateDispatcher); ok {
+		C.Last = alternate.last
+	}
+	if T := M.Timetable; T != nil {
+		index := make(map[*PlannedTrain]int, len(T.Trains))
+		for i, train := range T.Trains {
+			index[train] = i
+		}
+		C.Runs = make(map[int]RunState, len(T.runs))
+		for id, run := range T.runs {
+			C.Runs[id] = RunState{index[run.Train], run.Ready, run.Arrivals, run.Departures}
+		}
+	}
+	if E := M.Energy; E != nil {
+		C.Energy = &EnergyState{E.trains, E.types, E.sections, E.stations}
+	}
+	if F := M.Fleet; F != nil {
+		C.Fleet = &FleetState{F.crews, F.departures, F.busy, F.crewBusy, F.departed, F.cancelled, F.uncrewed, F.units, F.due, F.held}
+	}
+	if P := M.Passengers; P != nil {
+		C.Passengers = &PassengerState{P.queues, P.next, P.loads, P.dwelled, P.dwells, P.boarded, P.alighted, P.leftBehind, P.waiting}
+	}
+	if M.Journal != nil {
+		C.Movements = M.Journal.Movements
+	}
+	return C, nil
+}
+
+// Save writes checkpoint to file.
+func (C *Checkpoint) Save(FileName string) error {
+	data, err := json.Marshal(C)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(FileName, data, 0644)
+}
+
+// Copy returns independent copy of checkpoint.
+func (C *Checkpoint) Copy() (*Checkpoint, error) {
+	data, err := json.Marshal(C)
+	if err != nil {
+		return nil, err
+	}
+	clone := &Checkpoint{}
+	return clone, json.Unmarshal(data, clone)
+}
+
+// LoadCheckpoint reads checkpoint from file.
+func LoadCheckpoint(FileName string) (*Checkpoint, error) {
+	data, err := os.ReadFile(FileName)
+	if err != nil {
+		return nil, err
+	}
+	C := &Checkpoint{}
+	if err := json.Unmarshal(data, C); err != nil {
+		return nil, errors.New(fmt.Sprintf("%s: %s", FileName, err))
+	}
+	return C, nil
+}
+
+// Restore returns simulator and random source in state of checkpoint and restores state of dispatcher and layers of model.
+// Model must have the same layers as model of saved run.
+func (C *Checkpoint) Restore(M *Model, D Dispatcher) (*sim.Sim, sim.Source, error) {
+	if C.Dispatch != D.Name() {
+		return nil, nil, errors.New(fmt.Sprintf("checkpoint is saved with %s dispatching policy", C.Dispatch))
+	}
+	if C.Sim.Points != M.Points {
+		return nil, nil, errors.New(fmt.Sprintf("checkpoint is saved for model with %d points, model has %d", C.Sim.Points, M.Points))
+	}
+	if (C.Runs != nil) != (M.Timetable != nil) || (C.Energy != nil) != (M.Energy != nil) ||
+		(C.Fleet != nil) != (M.Fleet != nil) || (C.Passengers != nil) != (M.Passengers != nil) {
+		return nil, nil, errors.New("checkpoint is saved for model with other timetable, energy, fleet or passengers")
+	}
+	S, err := sim.Restore(C.Sim)
+	if err != nil {
+		return nil, nil, err
+	}
+	if M.KeepValues {
+		S.KeepValues()
+	}
+	M.ready = C.Ready
+	if M.ready == nil {
+		M.ready = make(map[int]float64)
+	}
+	if alternate, ok := D.(*AlternateDispatcher); ok && C.Last != nil {
+		alternate.last = C.Last
+	}
+	if T := M.Timetable; T != nil {
+		T.runs = make(map[int]*Run, len(C.Runs))
+		for id, run := range C.Runs {
+			if run.Train < 0 || run.Train >= len(T.Trains) {
+				return nil, nil, errors.New(fmt.Sprintf("unknown planned train %d in checkpoint", run.Train))
+			}
+			T.runs[id] = &Run{T.Trains[run.Train], run.Ready, run.Arrivals, run.Departures}
+		}
+	}
+	if E, state := M.Energy, C.Energy; E != nil {
+		E.trains, E.types, E.sections, E.stations = state.Trains, state.Types, state.Sections, state.Stations
+	}
+	if F, state := M.Fleet, C.Fleet; F != nil {
+		F.crews, F.departures, F.busy, F.crewBusy = state.Crews, state.Departures, state.Busy, state.CrewBusy
+		F.departed, F.cancelled, F.uncrewed = state.Departed, state.Cancelled, state.Uncrewed
+		F.units, F.due, F.held = state.Units, state.Due, state.Held
+	}
+	if P, state := M.Passengers, C.Passengers; P != nil {
+		P.queues, P.next, P.loads, P.dwelled, P.dwells = state.Queues, state.Next, state.Loads, state.Dwelled, state.Dwells
+		P.boarded, P.alighted, P.leftBehind, P.waiting = state.Boarded, state.Alighted, state.LeftBehind, state.Waiting
+	}
+	if M.Journal != nil {
+		M.Journal.Movements = C.Movements
+	}
+	var R sim.Source = sim.RestoreStream(C.Seed, C.Draws)
+	if C.Antithetic {
+		R = sim.Antithetic{R}
+	}
+	if M.Checkpoints != nil {
+		M.Checkpoints.Reset(S.GetSimTime())
+	}
+	return S, R, nil
+}
+
+// Reset sets end of period after time.
+func (K *Checkpoints) Reset(Time float64) {
+	K.next = (math.Floor(Time/K.Every) + 1) * K.Every
+}
+
+// Save writes checkpoint of simulation run if its period is over.
+func (K *Checkpoints) Save(S *sim.Sim, R sim.Source, M *Model, D Dispatcher) {
+	if S.GetSimTime() < K.next {
+		return
+	}
+	K.Reset(S.GetSimTime())
+	C, err := NewCheckpoint(S, R, M, D)
+	if err == nil {
+		err = C.Save(fmt.Sprintf("%s%04.0f.json", K.Prefix, S.GetSimTime()))
+	}
+	if err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"simulation-modeling/sim"
+	"strings"
+)
+
+// Model configuration stored as JSON file.
+// Line is used instead of crossing loop model if specified.
+// Track lengths are set by name of station, length of trains is set by timing "length".
+// Transit timings "ac" and "bc" of crossing loop are used in both directions, they are computed by motion
+// of train in each direction if their profiles are set.
+type Config struct {
+	Timings      map[string]DistributionConfig `json:"timings"`
+	Line         *LineConfig                   `json:"line,omitempty"`
+	TrackLengths map[string][]float64          `json:"track_lengths,omitempty"`
+	Train        *TrainConfig                  `json:"train,omitempty"`
+	Profiles     map[string]ProfileConfig      `json:"profiles,omitempty"`
+	Energy       *EnergyConfig                 `json:"energy,omitempty"`
+	Fleet        *FleetConfig                  `json:"fleet,omitempty"`
+	Passengers   *PassengerConfig              `json:"passengers,omitempty"`
+}
+
+// Name and parameters of distribution in configuration.
+type DistributionConfig struct {
+	Distribution string    `json:"distribution"`
+	Parameters   []float64 `json:"parameters"`
+}
+
+// Names of configurable timings.
+var TimingNames = map[string]int{
+	"station": Station,
+	"ac":      AC,
+	"bc":      BC,
+	"delay":   Delay,
+	"length":  Length,
+}
+
+func DefaultConfig() Config {
+	return Config{Timings: map[string]DistributionConfig{
+		"station": {"uniform", []float64{35, 55}},
+		"ac":      {"uniform", []float64{12, 18}},
+		"bc":      {"uniform", []float64{17, 23}},
+		"delay":   {"exponential", []float64{2}},
+	}}
+}
+
+func LoadConfig(FileName string) (Config, error) {
+	config := DefaultConfig()
+	data, err := os.ReadFile(FileName)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, errors.New(fmt.Sprintf("incorrect configuration in %s: %s", FileName, err))
+	}
+	return config, nil
+}
+
+func (C Config) Save(FileName string) error {
+	data, err := json.MarshalIndent(C, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(FileName, append(data, '\n'), 0644)
+}
+
+func (C Config) TimeTable(Duration float64) (map[int]sim.Distribution, error) {
+	timings := map[int]sim.Distribution{Timer: sim.Pair{Duration * 60, Duration * 60}}
+	for name, timing := range C.Timings {
+		index, ok := TimingNames[name]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("unknown timing in configuration: %q", name))
+		}
+		distribution, err := sim.NewDistribution(timing.Distribution, timing.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		timings[index] = distribution
+	}
+	return timings, nil
+}
+
+func (C Config) Model(Duration float64) (*Model, error) {
+	timings, err := C.TimeTable(Duration)
+	if err != nil {
+		return nil, err
+	}
+	timings[CA], timings[CB] = timings[AC], timings[BC]
+	model := CrossingLoop(timings)
+	for name, profile := range C.Profiles {
+		section := -1
+		for i := range model.Sections {
+			if strings.EqualFold(model.Sections[i].Name, name) {
+				section = i
+			}
+		}
+		switch {
+		case C.Line != nil:
+			return nil, errors.New("profiles of line are set by its sections")
+		case section < 0:
+			return nil, errors.New(fmt.Sprintf("unknown section in profiles: %q", name))
+		case C.Train == nil:
+			return nil, errors.New("train isn't configured for profiles")
+		}
+		terminal := [2]bool{model.Stations[section].Terminal, model.Stations[section+1].Terminal}
+		for direction, timing := range [][2]int{{AC, CA}, {BC, CB}}[section] {
+			running, err := C.Train.Run(profile, direction, terminal)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("profile of section %s: %s", name, err))
+			}
+			model.Sections[section].Running[direction] = running
+			if timings[timing], err = profile.Timing(running.Time); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if C.Line != nil {
+		if model, err = BuildLine(*C.Line, C.Train, timings); err != nil {
+			return nil, err
+		}
+	}
+	if C.Energy != nil {
+		if model.Energy, err = NewEnergy(*C.Energy, model); err != nil {
+			return nil, err
+		}
+	}
+	if C.Fleet != nil {
+		if model.Fleet, err = NewFleet(*C.Fleet, model); err != nil {
+			return nil, err
+		}
+	}
+	if C.Passengers != nil {
+		if model.Passengers, err = NewPassengers(*C.Passengers, model); err != nil {
+			return nil, err
+		}
+	}
+	if len(C.TrackLengths) != 0 {
+		model.Lengths = make(map[int]float64)
+	}
+	for name, lengths := range C.TrackLengths {
+		i, ok := model.StationByName(name)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("unknown station in track lengths: %q", name))
+		}
+		if len(lengths) != len(model.Stations[i].Tracks) {
+			return nil, errors.New(fmt.Sprintf("station %s has %d tracks, got %d lengths", name, len(model.Stations[i].Tracks), len(lengths)))
+		}
+		for j, length := range lengths {
+			model.Lengths[model.Stations[i].Tracks[j]] = length
+		}
+	}
+	return model, nil
+}
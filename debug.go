@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"simulation-modeling/sim"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of states of points in debugger.
+var stateNames = map[int]string{sim.NAvailable: "na", sim.NUsed: "free", sim.Used: "used"}
+
+// Breakpoint stops simulation after phase in which point is seized or released or transaction is moved.
+type Breakpoint struct {
+	Kind  string
+	Value int
+}
+
+// Debugger runs simulation step by step, one step is one phase of current events chain.
+type Debugger struct {
+	S           *sim.Sim
+	R           sim.Source
+	M           *Model
+	D           Dispatcher
+	Breakpoints []Breakpoint
+	Output      io.Writer
+	events      *phaseEvents
+}
+
+// Events of simulator in one phase: seized and released points and transactions of current events chain or moved.
+type phaseEvents struct {
+	sim.NopObserver
+	seized, released, transactions map[int]bool
+}
+
+func (E *phaseEvents) Extract(Time float64, Cec []*sim.Transaction) {
+	for _, tr := range Cec {
+		E.transactions[sim.GetId(*tr)] = true
+	}
+}
+
+func (E *phaseEvents) Seize(Time float64, Tr *sim.Transaction, Point int) {
+	E.seized[Point] = true
+	if Tr != nil {
+		E.transactions[sim.GetId(*Tr)] = true
+	}
+}
+
+func (E *phaseEvents) Release(Time float64, Tr *sim.Transaction, Point int) {
+	E.released[Point] = true
+}
+
+func NewDebugger(R sim.Source, M *Model, D Dispatcher, Output io.Writer) *Debugger {
+	G := &Debugger{S: Start(R, M, nil), R: R, M: M, D: D, Output: Output, events: &phaseEvents{}}
+	G.S.AddObserver(G.events)
+	return G
+}
+
+// Step runs one phase, it returns description of hit breakpoint or empty string.
+func (G *Debugger) Step() string {
+	G.events.seized, G.events.released, G.events.transactions = make(map[int]bool), make(map[int]bool), make(map[int]bool)
+	Phases(G.S, G.R, G.M, G.D)
+
+	for i, breakpoint := range G.Breakpoints {
+		hit := false
+		switch breakpoint.Kind {
+		case "seize":
+			hit = G.events.seized[breakpoint.Value]
+		case "release":
+			hit = G.events.released[breakpoint.Value]
+		case "tr":
+			hit = G.events.transactions[breakpoint.Value]
+		}
+		if hit {
+			return fmt.Sprintf("breakpoint %d: %s %d", i+1, breakpoint.Kind, breakpoint.Value)
+		}
+	}
+	return ""
+}
+
+// Run makes steps until breakpoint is hit, simulation is finished or condition is false.
+func (G *Debugger) Run(Condition func(step int) bool) {
+	for step := 0; Condition(step); step++ {
+		if G.S.IsFinish() {
+			fmt.Fprintln(G.Output, "simulation is finished")
+			return
+		}
+		if hit := G.Step(); hit != "" {
+			fmt.Fprintf(G.Output, "%s at %.2f\n", hit, G.S.GetSimTime())
+			return
+		}
+	}
+	fmt.Fprintf(G.Output, "time %.2f\n", G.S.GetSimTime())
+}
+
+func (G *Debugger) label(Point int) string {
+	switch Point {
+	case Point0:
+		return "outside"
+	case G.M.Clock:
+		return "clock"
+	}
+	return PointLabel(G.M, Point)
+}
+
+func (G *Debugger) point(Value string) (int, error) {
+	p, err := strconv.Atoi(Value)
+	if err != nil || p < 0 || p >= G.M.Points {
+		return 0, errors.New(fmt.Sprintf("incorrect point: %q", Value))
+	}
+	return p, nil
+}
+
+// Execute runs command of debugger, it returns false on quit.
+func (G *Debugger) Execute(Command string) bool {
+	fields := strings.Fields(Command)
+	if len(fields) == 0 {
+		return true
+	}
+	arguments := fields[1:]
+	fail := func(err error) { fmt.Fprintln(G.Output, err) }
+	switch fields[0] {
+	case "help", "h":
+		fmt.Fprint(G.Output, `step [N]              run N phases of current events chain (default 1)
+until T               run until simulation time T in minutes or HH:MM
+continue              run until breakpoint or end of simulation
+break seize|release P stop after point P is seized or released
+break tr ID           stop after phase with transaction ID
+breaks                list breakpoints
+delete N              delete breakpoint N
+fec                   show future event chain
+waitlist              show waitlist
+points                show states of points
+state                 show detailed state of simulator
+set P free|used|na    set state of point P
+interrupt ID          take transaction out of future event chain keeping its remaining time
+resume ID             return interrupted transaction to future event chain
+reschedule ID T       move event of transaction to time T in minutes or HH:MM
+quit                  stop debugging
+`)
+	case "step", "s":
+		n := 1
+		if len(arguments) == 1 {
+			var err error
+			if n, err = strconv.Atoi(arguments[0]); err != nil || n < 1 {
+				fail(errors.New(fmt.Sprintf("incorrect number of steps: %q", arguments[0])))
+				return true
+			}
+		}
+		G.Run(func(step int) bool { return step < n })
+	case "until", "u":
+		if len(arguments) != 1 {
+			fail(errors.New("usage: until T"))
+			return true
+		}
+		t, err := ParseClock(arguments[0])
+		if err != nil {
+			fail(err)
+			return true
+		}
+		G.Run(func(int) bool {
+			future := G.S.GetFuture()
+			return len(future) != 0 && sim.GetTime(*future[0]) <= t
+		})
+	case "continue", "c":
+		G.Run(func(int) bool { return true })
+	case "break", "b":
+		if len(arguments) != 2 || arguments[0] != "seize" && arguments[0] != "release" && arguments[0] != "tr" {
+			fail(errors.New("usage: break seize|release P or break tr ID"))
+			return true
+		}
+		value, err := strconv.Atoi(arguments[1])
+		if arguments[0] != "tr" {
+			value, err = G.point(arguments[1])
+		}
+		if err != nil {
+			fail(err)
+			return true
+		}
+		G.Breakpoints = append(G.Breakpoints, Breakpoint{arguments[0], value})
+		fmt.Fprintf(G.Output, "breakpoint %d: %s %d\n", len(G.Breakpoints), arguments[0], value)
+	case "breaks":
+		for i, breakpoint := range G.Breakpoints {
+			fmt.Fprintf(G.Output, "%d: %s %d\n", i+1, breakpoint.Kind, breakpoint.Value)
+		}
+	case "delete", "d":
+		i, err := strconv.Atoi(strings.Join(arguments, ""))
+		if err != nil || i < 1 || i > len(G.Breakpoints) {
+			fail(errors.New(fmt.Sprintf("incorrect breakpoint: %q", strings.Join(arguments, " "))))
+			return true
+		}
+		G.Breakpoints = append(G.Breakpoints[:i-1], G.Breakpoints[i:]...)
+	case "fec":
+		for _, tr := range G.S.GetFuture() {
+			fmt.Fprintf(G.Output, "%s %s -> %s\n", tr, G.label(sim.GetPoints(*tr).Current), G.label(sim.GetPoints(*tr).Next))
+		}
+		for _, tr := range G.S.GetInterrupted() {
+			remaining, _ := G.S.GetRemaining(sim.GetId(*tr))
+			fmt.Fprintf(G.Output, "%s %s -> %s, interrupted, remaining %.2f\n", tr, G.label(sim.GetPoints(*tr).Current),
+				G.label(sim.GetPoints(*tr).Next), remaining)
+		}
+	case "waitlist", "w":
+		for _, tr := range G.S.GetWaitlist() {
+			fmt.Fprintf(G.Output, "%s %s -> %s, waiting %.2f\n", tr, G.label(sim.GetPoints(*tr).Current),
+				G.label(sim.GetPoints(*tr).Next), G.S.GetSimTime()-sim.GetTime(*tr))
+		}
+	case "points", "p":
+		for p := 0; p < G.M.Points; p++ {
+			state, _ := G.S.GetPointState(p)
+			fmt.Fprintf(G.Output, "%3d %-28s %s\n", p, G.label(p), stateNames[state])
+		}
+	case "state":
+		fmt.Fprintln(G.Output, G.S, G.S.DebugString())
+	case "set":
+		if len(arguments) != 2 {
+			fail(errors.New("usage: set P free|used|na"))
+			return true
+		}
+		p, err := G.point(arguments[0])
+		if err != nil {
+			fail(err)
+			return true
+		}
+		for state, name := range stateNames {
+			if name == arguments[1] {
+				if err := G.S.SetPointState(p, state); err != nil {
+					fail(err)
+					return true
+				}
+				fmt.Fprintf(G.Output, "%d %s %s\n", p, G.label(p), name)
+				return true
+			}
+		}
+		fail(errors.New(fmt.Sprintf("incorrect state: %q", arguments[1])))
+	case "interrupt", "resume", "reschedule":
+		if len(arguments) != 1 && fields[0] != "reschedule" || len(arguments) != 2 && fields[0] == "reschedule" {
+			fail(errors.New("usage: interrupt ID, resume ID or reschedule ID T"))
+			return true
+		}
+		id, err := strconv.Atoi(arguments[0])
+		if err != nil {
+			fail(errors.New(fmt.Sprintf("incorrect transaction: %q", arguments[0])))
+			return true
+		}
+		switch fields[0] {
+		case "interrupt":
+			remaining, err := G.S.Interrupt(id)
+			if err != nil {
+				fail(err)
+				return true
+			}
+			fmt.Fprintf(G.Output, "transaction %d interrupted, remaining time %.2f\n", id, remaining)
+		case "resume":
+			if err := G.S.ResumeInterrupted(id); err != nil {
+				fail(err)
+				return true
+			}
+			tr, _ := G.S.Pending(id)
+			fmt.Fprintf(G.Output, "transaction %d resumed until %.2f\n", id, sim.GetTime(*tr))
+		case "reschedule":
+			t, err := ParseClock(arguments[1])
+			if err == nil {
+				err = G.S.Reschedule(id, t)
+			}
+			if err != nil {
+				fail(err)
+				return true
+			}
+			fmt.Fprintf(G.Output, "transaction %d rescheduled to %.2f\n", id, t)
+		}
+	case "quit", "q":
+		return false
+	default:
+		fail(errors.New(fmt.Sprintf("unknown command %q, type help for list of commands", fields[0])))
+	}
+	return true
+}
+
+// Debug runs interactive debugger of simulation.
+func Debug(Arguments []string) {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s debug [-c FILE] [-d DURATION] [-s SEED] [-dispatch POLICY] [-timetable FILE]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	configFlag := flags.String("c", "", "read model configuration from file")
+	durationFlag := flags.Float64("d", 24, "set simulation duration in hours")
+	seedFlag := flags.Int64("s", 0, "set seed of random stream (default: current time)")
+	dispatchFlag := flags.String("dispatch", "fifo", "set dispatching policy: fifo, priority, alternate or lookahead")
+	timetableFlag := flags.String("timetable", "", "read planned trains from CSV file with train, station, arrival and departure")
+	flags.Parse(Arguments)
+
+	config := DefaultConfig()
+	if *configFlag != "" {
+		var err error
+		if config, err = LoadConfig(*configFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	model, err := config.Model(*durationFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	dispatcher, err := NewDispatcher(*dispatchFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *timetableFlag != "" {
+		if model.Timetable, err = ReadTimetable(*timetableFlag, model); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		model.RoadMap = TraceRoadMap(model.RoadMap, model.Timetable.Stations(model))
+	}
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	debugger := NewDebugger(NewStream(seed, 0, false), model, dispatcher, os.Stdout)
+	fmt.Printf("%s, seed %d, type help for list of commands\n", model.Title, seed)
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("(debug) ")
+		if !scanner.Scan() || !debugger.Execute(scanner.Text()) {
+			return
+		}
+	}
+}
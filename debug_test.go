@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"simulation-modeling/sim"
+	"strings"
+	"testing"
+)
+
+// testDebugger starts debugger of crossing loop and executes commands, one command on each line.
+func testDebugger(t *testing.T, Commands string) (*Debugger, *bytes.Buffer) {
+	var output bytes.Buffer
+	G := NewDebugger(NewStream(1, 0, false), testModel(t, nil, 24), FIFODispatcher{}, &output)
+	for _, command := range strings.Split(Commands, "\n") {
+		if !G.Execute(command) {
+			t.Fatalf("Unexpected quit by command %q", command)
+		}
+	}
+	return G, &output
+}
+
+func TestDebuggerStep(t *testing.T) {
+	G, output := testDebugger(t, "step 3\nstep 0\nstep x")
+	if !strings.HasPrefix(output.String(), fmt.Sprintf("time %.2f\n", G.S.GetSimTime())) {
+		t.Errorf("Expected time after steps, got %q", output)
+	}
+	if strings.Count(output.String(), "incorrect number of steps") != 2 {
+		t.Errorf("Expected errors of incorrect steps, got %q", output)
+	}
+
+	G, output = testDebugger(t, "until 2:00")
+	if now, future := G.S.GetSimTime(), G.S.GetFuture(); now > 120 || len(future) == 0 || sim.GetTime(*future[0]) <= 120 {
+		t.Errorf("Expected to stop at last phase before 120, got %.2f with next event %v", now, future)
+	}
+	if !strings.Contains(output.String(), "time ") {
+		t.Errorf("Expected time after until, got %q", output)
+	}
+}
+
+func TestDebuggerBreak(t *testing.T) {
+	G, output := testDebugger(t, fmt.Sprintf("break seize %d\nbreak release %d\nbreak tr 3\nbreaks\ndelete 3\ncontinue", PointA, PointAC))
+	// The first train seizes its terminal before it releases section.
+	if state, _ := G.S.GetPointState(PointA); state != sim.Used || len(G.Breakpoints) != 2 {
+		t.Errorf("Expected point %d used at breakpoint, got state %d and breakpoints %v", PointA, state, G.Breakpoints)
+	}
+	if !strings.Contains(output.String(), fmt.Sprintf("3: tr 3\nbreakpoint 1: seize %d at", PointA)) {
+		t.Errorf("Expected list of breakpoints and hit of seize, got %q", output)
+	}
+
+	output.Reset()
+	G.Execute("delete 1")
+	G.Execute("continue")
+	if state, _ := G.S.GetPointState(PointAC); state == sim.Used || !strings.HasPrefix(output.String(), "breakpoint 1: release") {
+		t.Errorf("Expected hit of release of point %d, got state %d and %q", PointAC, state, output)
+	}
+
+	output.Reset()
+	G.Execute("delete 1")
+	G.Execute("break tr 1")
+	G.Execute("continue")
+	// Timer transaction ends simulation.
+	if !strings.HasSuffix(output.String(), "breakpoint 1: tr 1 at 1440.00\n") {
+		t.Errorf("Expected hit of timer transaction at end, got %q", output)
+	}
+
+	output.Reset()
+	for _, command := range []string{"break seize", "break tr x", "break seize 100", "delete 5", "xyz"} {
+		G.Execute(command)
+	}
+	if lines := strings.Count(output.String(), "\n"); lines != 5 || len(G.Breakpoints) != 1 {
+		t.Errorf("Expected 5 errors without new breakpoints, got %q and %v", output, G.Breakpoints)
+	}
+	if G.Execute("quit") {
+		t.Errorf("Expected false on quit")
+	}
+}
+
+func TestDebuggerSet(t *testing.T) {
+	G, output := testDebugger(t, fmt.Sprintf("set %d used\nset %d na\nset %d xx\nset 100 free", PointAC, PointCm, PointA))
+	tests := []struct {
+		point, state int
+	}{
+		{PointAC, sim.Used},
+		{PointCm, sim.NAvailable},
+		{PointA, sim.NUsed},
+	}
+	for _, test := range tests {
+		if state, _ := G.S.GetPointState(test.point); state != test.state {
+			t.Errorf("Expected state %d of point %d, got %d", test.state, test.point, state)
+		}
+	}
+	if !strings.Contains(output.String(), "incorrect state") || !strings.Contains(output.String(), "incorrect point") {
+		t.Errorf("Expected errors of state and point, got %q", output)
+	}
+}
+
+func TestDebuggerInterrupt(t *testing.T) {
+	G, output := testDebugger(t, "step 5")
+	tr := G.S.GetFuture()[0]
+	id, time := sim.GetId(*tr), sim.GetTime(*tr)
+
+	// Interrupted transaction keeps its remaining time and returns to the same event after resume.
+	G.Execute(fmt.Sprintf("interrupt %d", id))
+	if _, ok := G.S.Pending(id); ok || len(G.S.GetInterrupted()) != 1 {
+		t.Fatalf("Expected transaction %d out of future event chain, got %v", id, G.S.GetFuture())
+	}
+	G.Execute(fmt.Sprintf("resume %d", id))
+	if tr, ok := G.S.Pending(id); !ok || sim.GetTime(*tr) != time || len(G.S.GetInterrupted()) != 0 {
+		t.Errorf("Expected transaction %d resumed until %.2f, got %v", id, time, tr)
+	}
+	expected := fmt.Sprintf("transaction %d interrupted, remaining time %.2f\ntransaction %d resumed until %.2f\n",
+		id, time-G.S.GetSimTime(), id, time)
+	if !strings.HasSuffix(output.String(), expected) {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+
+	output.Reset()
+	G.Execute("resume 1000")
+	G.Execute("interrupt")
+	if strings.Count(output.String(), "\n") != 2 {
+		t.Errorf("Expected errors of unknown transaction and usage, got %q", output)
+	}
+}
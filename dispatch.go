@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"simulation-modeling/sim"
+	"sort"
+)
+
+// Dispatcher chooses order in which waiting trains are offered freed sections and tracks.
+// Order is consulted after each current events chain, Dispatched is called for each train before it moves
+// to next waypoint. Train of current events chain isn't ordered: it takes free point at once, before trains
+// of waitlist, so arriving train can take point released in the same phase from waiting train of higher priority.
+type Dispatcher interface {
+	Name() string
+	Order(S *sim.Sim, M *Model, Waiting []*sim.Transaction) []*sim.Transaction
+	Dispatched(M *Model, Tr *sim.Transaction)
+}
+
+// NewDispatcher returns dispatcher by name of policy: "fifo", "priority", "alternate" or "lookahead".
+func NewDispatcher(Name string) (Dispatcher, error) {
+	switch Name {
+	case "fifo":
+		return FIFODispatcher{}, nil
+	case "priority":
+		return PriorityDispatcher{}, nil
+	case "alternate":
+		return &AlternateDispatcher{make(map[int]int)}, nil
+	case "lookahead":
+		return LookaheadDispatcher{}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("unknown dispatching policy: %q", Name))
+}
+
+// First in, first out: trains are served in order of waitlist, it keeps order of arrival to waiting points.
+type FIFODispatcher struct{}
+
+func (D FIFODispatcher) Name() string {
+	return "fifo"
+}
+
+func (D FIFODispatcher) Order(S *sim.Sim, M *Model, Waiting []*sim.Transaction) []*sim.Transaction {
+	return Waiting
+}
+
+func (D FIFODispatcher) Dispatched(M *Model, Tr *sim.Transaction) {}
+
+// Priority first: trains with higher priority are served first, trains with equal priority are served FIFO.
+type PriorityDispatcher struct{}
+
+func (D PriorityDispatcher) Name() string {
+	return "priority"
+}
+
+func (D PriorityDispatcher) Order(S *sim.Sim, M *Model, Waiting []*sim.Transaction) []*sim.Transaction {
+	sort.SliceStable(Waiting, func(i, j int) bool { return sim.GetPriority(*Waiting[i]) > sim.GetPriority(*Waiting[j]) })
+	return Waiting
+}
+
+func (D PriorityDispatcher) Dispatched(M *Model, Tr *sim.Transaction) {}
+
+// Direction alternating: section is offered to trains of direction opposite to last train entered it.
+type AlternateDispatcher struct {
+	last map[int]int
+}
+
+func (D *AlternateDispatcher) Name() string {
+	return "alternate"
+}
+
+func (D *AlternateDispatcher) Order(S *sim.Sim, M *Model, Waiting []*sim.Transaction) []*sim.Transaction {
+	preferred := func(tr *sim.Transaction) bool {
+		section, direction := M.Heading(sim.GetPoints(*tr))
+		last, ok := D.last[section]
+		return !ok || last != direction
+	}
+	sort.SliceStable(Waiting, func(i, j int) bool { return preferred(Waiting[i]) && !preferred(Waiting[j]) })
+	return Waiting
+}
+
+func (D *AlternateDispatcher) Dispatched(M *Model, Tr *sim.Transaction) {
+	points := sim.GetPoints(*Tr)
+	if _, ok := M.sectionIndex(points.Next); !ok {
+		return
+	}
+	section, direction := M.Heading(points)
+	D.last[section] = direction
+}
+
+// Minimize total delay by one step lookahead: for each waiting train delay which its departure
+// imposes on other waiting trains and on trains approaching the same section is estimated by mean
+// occupation time of section, delay of trains blocked by points it releases is subtracted.
+// Trains are served in order of estimated delay.
+type LookaheadDispatcher struct{}
+
+func (D LookaheadDispatcher) Name() string {
+	return "lookahead"
+}
+
+func (D LookaheadDispatcher) Order(S *sim.Sim, M *Model, Waiting []*sim.Transaction) []*sim.Transaction {
+	future := S.GetFuture()
+	delay := make(map[int]float64, len(Waiting))
+	for _, tr := range Waiting {
+		points := sim.GetPoints(*tr)
+		section, _ := M.Heading(points)
+		occupation := M.Occupation(points)
+		needs := make(map[int]bool)
+		for _, point := range append(M.CheckTable[points], section, points.Next) {
+			needs[point] = true
+		}
+		for _, other := range Waiting {
+			if other == tr {
+				continue
+			}
+			for _, point := range M.CheckTable[sim.GetPoints(*other)] {
+				if needs[point] {
+					delay[sim.GetId(*tr)] += occupation
+					break
+				}
+				if point == points.Current {
+					delay[sim.GetId(*tr)] -= occupation
+					break
+				}
+			}
+		}
+		for _, other := range future {
+			if sim.GetTime(*other)-S.GetSimTime() > occupation {
+				break
+			}
+			if upcoming, ok := M.Upcoming(sim.GetPoints(*other)); !ok {
+				continue
+			} else if next, _ := M.Heading(upcoming); next == section && section != Point0 {
+				delay[sim.GetId(*tr)] += occupation - (sim.GetTime(*other) - S.GetSimTime())
+			}
+		}
+	}
+	sort.SliceStable(Waiting, func(i, j int) bool { return delay[sim.GetId(*Waiting[i])] < delay[sim.GetId(*Waiting[j])] })
+	return Waiting
+}
+
+func (D LookaheadDispatcher) Dispatched(M *Model, Tr *sim.Transaction) {}
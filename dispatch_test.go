@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"simulation-modeling/sim"
+	"testing"
+)
+
+// waiting returns train which waits by time of arrival to waiting point.
+func waiting(Id int, Time float64, Priority int, Points sim.Points) *sim.Transaction {
+	tr := sim.NewTransaction(Id, Time, Points.Current)
+	tr.CorrectTime(0, Points.Next)
+	tr.SetPriority(Priority)
+	return tr
+}
+
+func ids(Waiting []*sim.Transaction) []int {
+	var result []int
+	for _, tr := range Waiting {
+		result = append(result, sim.GetId(*tr))
+	}
+	return result
+}
+
+// recorder keeps order of trains dispatched by policy.
+type recorder struct {
+	Dispatcher
+	dispatched []int
+}
+
+func (D *recorder) Dispatched(M *Model, Tr *sim.Transaction) {
+	D.dispatched = append(D.dispatched, sim.GetId(*Tr))
+	D.Dispatcher.Dispatched(M, Tr)
+}
+
+func TestNewDispatcher(t *testing.T) {
+	for _, name := range []string{"fifo", "priority", "alternate", "lookahead"} {
+		if D, err := NewDispatcher(name); err != nil || D.Name() != name {
+			t.Errorf("Expected dispatcher %s, got %v, %v", name, D, err)
+		}
+	}
+	if _, err := NewDispatcher("random"); err == nil {
+		t.Errorf("Expected error of unknown policy")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	M := testModel(t, nil, 24)
+	S := sim.New(M.Points)
+	S.Init()
+	tests := []struct {
+		dispatcher Dispatcher
+		waiting    []*sim.Transaction
+		order      []int
+	}{
+		// Waitlist keeps order of arrival, FIFO doesn't reorder it.
+		{FIFODispatcher{},
+			[]*sim.Transaction{waiting(1, 10, 0, sim.Points{PointCm, PointBC}), waiting(2, 12, 1, sim.Points{Point0, PointA}), waiting(3, 11, 0, sim.Points{PointCr, PointAC})},
+			[]int{1, 2, 3}},
+		{PriorityDispatcher{},
+			[]*sim.Transaction{waiting(1, 10, 0, sim.Points{PointCm, PointBC}), waiting(2, 12, 1, sim.Points{Point0, PointA}), waiting(3, 11, 0, sim.Points{PointCr, PointAC})},
+			[]int{2, 1, 3}},
+		// Last train entered AC from A, train from C to A is preferred to train from A.
+		{&AlternateDispatcher{map[int]int{PointAC: 0}},
+			[]*sim.Transaction{waiting(1, 10, 0, sim.Points{Point0, PointA}), waiting(2, 12, 0, sim.Points{PointCm, PointBC}), waiting(3, 11, 0, sim.Points{PointCr, PointAC})},
+			[]int{2, 3, 1}},
+		// Train leaving Cm for A frees track which train from B waits for.
+		{LookaheadDispatcher{},
+			[]*sim.Transaction{waiting(1, 10, 0, sim.Points{Point0, PointB}), waiting(2, 12, 0, sim.Points{PointCm, PointAC})},
+			[]int{2, 1}},
+	}
+
+	for _, test := range tests {
+		if order := ids(test.dispatcher.Order(S, M, test.waiting)); !reflect.DeepEqual(order, test.order) {
+			t.Errorf("Expected order %v of %s dispatcher, got %v", test.order, test.dispatcher.Name(), order)
+		}
+	}
+}
+
+func TestAlternateDispatched(t *testing.T) {
+	M := testModel(t, nil, 24)
+	D := &AlternateDispatcher{make(map[int]int)}
+	tests := []struct {
+		points sim.Points
+		last   map[int]int
+	}{
+		// Movement to origin and to track of loop doesn't enter section.
+		{sim.Points{Point0, PointA}, map[int]int{}},
+		{sim.Points{PointA, PointAC}, map[int]int{PointAC: 0}},
+		{sim.Points{PointAC, PointCm}, map[int]int{PointAC: 0}},
+		{sim.Points{PointCr, PointAC}, map[int]int{PointAC: 1}},
+		{sim.Points{PointB, PointBC}, map[int]int{PointAC: 1, PointBC: 1}},
+	}
+
+	for _, test := range tests {
+		D.Dispatched(M, waiting(1, 0, 0, test.points))
+		if !reflect.DeepEqual(D.last, test.last) {
+			t.Errorf("Expected last directions %v after movement %v, got %v", test.last, test.points, D.last)
+		}
+	}
+}
+
+func TestDispatchWaitlist(t *testing.T) {
+	tests := []struct {
+		dispatcher Dispatcher
+		dispatched []int
+	}{
+		{FIFODispatcher{}, []int{1, 2}},
+		// Second train in waitlist is served first, the first one is still served in the same phase.
+		{PriorityDispatcher{}, []int{2, 1}},
+	}
+
+	for _, test := range tests {
+		M := testModel(t, nil, 24)
+		S := sim.New(M.Points)
+		S.Init()
+		// Both trains are released when clock ends current events chain.
+		S.AddToWaitlist(waiting(1, 0, 0, sim.Points{Point0, PointA}))
+		S.AddToWaitlist(waiting(2, 0, 1, sim.Points{Point0, PointB}))
+		S.Generate(1, M.Clock)
+		D := &recorder{test.dispatcher, nil}
+		Phases(S, NewStream(1, 0, false), M, D)
+		if !reflect.DeepEqual(D.dispatched, test.dispatched) || len(S.GetWaitlist()) != 0 {
+			t.Errorf("Expected trains %v dispatched by %s dispatcher, got %v with waitlist %v",
+				test.dispatched, test.dispatcher.Name(), D.dispatched, ids(S.GetWaitlist()))
+		}
+	}
+}
+
+func TestDispatchRun(t *testing.T) {
+	for _, name := range []string{"fifo", "priority", "alternate", "lookahead"} {
+		for seed := int64(1); seed <= 10; seed++ {
+			M := testModel(t, nil, 48)
+			D, _ := NewDispatcher(name)
+			S := simulate(M, D, seed)
+			checkOccupancy(t, M, S)
+			if n := trains(M); n < 100 {
+				t.Errorf("Expected more than 100 trains in 48 hours by %s dispatcher with seed %d, got %d", name, seed, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"simulation-modeling/sim"
+)
+
+// Energy rates of train type in configuration, type is identified by priority of trains.
+// Traction and idling are in kWh per minute of transit and waiting, restart is in kWh per restart after stop,
+// emission is in kg of CO2 per kWh.
+type EnergyRates struct {
+	Name     string  `json:"name"`
+	Priority int     `json:"priority,omitempty"`
+	Traction float64 `json:"traction"`
+	Idling   float64 `json:"idling"`
+	Restart  float64 `json:"restart"`
+	Emission float64 `json:"emission,omitempty"`
+}
+
+// Energy accounting in configuration: rates by train types.
+// Trains of priority without rates use rates of the first type.
+type EnergyConfig struct {
+	Types []EnergyRates `json:"types"`
+}
+
+// Energy used by train or on place of line, kWh.
+type EnergyUse struct {
+	Traction, Idling, Restart float64
+}
+
+// Total returns total energy, kWh.
+func (E EnergyUse) Total() float64 {
+	return E.Traction + E.Idling + E.Restart
+}
+
+func (E *EnergyUse) add(Other EnergyUse) {
+	E.Traction += Other.Traction
+	E.Idling += Other.Idling
+	E.Restart += Other.Restart
+}
+
+// Energy accounting of simulation run: energy is attributed to transactions, sections and stations.
+type Energy struct {
+	Config   EnergyConfig
+	trains   map[int]*EnergyUse
+	types    map[int]int
+	sections []EnergyUse
+	stations []EnergyUse
+}
+
+func NewEnergy(Config EnergyConfig, M *Model) (*Energy, error) {
+	if len(Config.Types) == 0 {
+		return nil, errors.New("energy accounting must have at least one train type")
+	}
+	for _, rates := range Config.Types {
+		if rates.Traction < 0 || rates.Idling < 0 || rates.Restart < 0 || rates.Emission < 0 {
+			return nil, errors.New(fmt.Sprintf("negative energy rates of train type %s", rates.Name))
+		}
+	}
+	E := &Energy{Config: Config}
+	E.Reset(M)
+	return E, nil
+}
+
+// Reset clears accounting before simulation run.
+func (E *Energy) Reset(M *Model) {
+	E.trains, E.types = make(map[int]*EnergyUse), make(map[int]int)
+	E.sections, E.stations = make([]EnergyUse, len(M.Sections)), make([]EnergyUse, len(M.Stations))
+}
+
+// rates returns index of train type of transaction.
+func (E *Energy) rates(Tr *sim.Transaction) int {
+	for i, rates := range E.Config.Types {
+		if rates.Priority == sim.GetPriority(*Tr) {
+			return i
+		}
+	}
+	return 0
+}
+
+func (E *Energy) train(Tr *sim.Transaction) *EnergyUse {
+	id := sim.GetId(*Tr)
+	if _, ok := E.trains[id]; !ok {
+		E.trains[id] = &EnergyUse{}
+		E.types[id] = E.rates(Tr)
+	}
+	return E.trains[id]
+}
+
+// Transit attributes traction energy of transaction entering section point for transit time.
+func (E *Energy) Transit(M *Model, Tr *sim.Transaction, Point int, Time float64) {
+	use := EnergyUse{Traction: E.Config.Types[E.rates(Tr)].Traction * Time}
+	E.train(Tr).add(use)
+	if j, ok := M.sectionIndex(Point); ok {
+		E.sections[j].add(use)
+	}
+}
+
+// Wait attributes idling energy of transaction standing by its waypoints to station or section where it stands
+// and restart energy to section it enters if it was stopped on line, not before terminal station.
+// Transaction stands while it waits for points, dwells on station or is held by timetable.
+func (E *Energy) Wait(M *Model, Tr *sim.Transaction, P sim.Points, Time float64) {
+	rates := E.Config.Types[E.rates(Tr)]
+	idling := EnergyUse{Idling: rates.Idling * Time}
+	E.train(Tr).add(idling)
+	place := P.Current
+	if place == Point0 {
+		place = P.Next
+	}
+	if i, ok := M.stationIndex(place); ok {
+		E.stations[i].add(idling)
+	} else if j, ok := M.sectionIndex(place); ok {
+		E.sections[j].add(idling)
+	}
+	if P.Current == Point0 {
+		return
+	}
+	restart := EnergyUse{Restart: rates.Restart}
+	E.train(Tr).add(restart)
+	if j, ok := M.sectionIndex(P.Next); ok {
+		E.sections[j].add(restart)
+	} else if j, ok := M.sectionIndex(P.Current); ok {
+		E.sections[j].add(restart)
+	}
+}
+
+// Total returns total energy of run.
+func (E *Energy) Total() EnergyUse {
+	total := EnergyUse{}
+	for _, use := range E.trains {
+		total.add(*use)
+	}
+	return total
+}
+
+// Emission returns total emission of run, kg.
+func (E *Energy) Emission() float64 {
+	emission := 0.0
+	for id, use := range E.trains {
+		emission += use.Total() * E.Config.Types[E.types[id]].Emission
+	}
+	return emission
+}
+
+func WriteEnergyReport(Writer *bufio.Writer, E *Energy, M *Model) {
+	total := E.Total()
+	WriteData(Writer, fmt.Sprintf("Energy consumption: %.1f kWh, traction %.1f, idling %.1f, restart %.1f\n",
+		total.Total(), total.Traction, total.Idling, total.Restart))
+	WriteData(Writer, fmt.Sprintf("Emission: %.1f kg\n", E.Emission()))
+	if len(E.trains) != 0 {
+		WriteData(Writer, fmt.Sprintf("Mean energy per train: %.2f kWh, emission %.2f kg\n",
+			total.Total()/float64(len(E.trains)), E.Emission()/float64(len(E.trains))))
+	}
+	for i, rates := range E.Config.Types {
+		use, trains := EnergyUse{}, 0
+		for id, train := range E.trains {
+			if E.types[id] == i {
+				use.add(*train)
+				trains++
+			}
+		}
+		if trains != 0 {
+			WriteData(Writer, fmt.Sprintf("Mean energy per %s train: %.2f kWh (%d trains)\n", rates.Name, use.Total()/float64(trains), trains))
+		}
+	}
+	for j, section := range M.Sections {
+		use := E.sections[j]
+		WriteData(Writer, fmt.Sprintf("Energy on %s section: %.1f kWh, traction %.1f, idling %.1f, restart %.1f\n",
+			section.Name, use.Total(), use.Traction, use.Idling, use.Restart))
+	}
+	for i, station := range M.Stations {
+		WriteData(Writer, fmt.Sprintf("Idling energy on %s: %.1f kWh\n", StationLabel(station), E.stations[i].Idling))
+	}
+}
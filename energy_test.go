@@ -0,0 +1,180 @@
+package main
+
+import (
+	"math"
+	"simulation-modeling/sim"
+	"testing"
+)
+
+var testRates = []EnergyRates{
+	{Name: "passenger", Traction: 2, Idling: 0.5, Restart: 4, Emission: 0.1},
+	{Name: "freight", Priority: 1, Traction: 3, Idling: 1, Restart: 8, Emission: 0.2},
+}
+
+func TestEnergy(t *testing.T) {
+	M := testModel(t, nil, 24)
+	E, err := NewEnergy(EnergyConfig{testRates}, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passenger, freight := waiting(1, 0, 0, sim.Points{PointA, PointAC}), waiting(2, 0, 1, sim.Points{PointB, PointBC})
+	E.Wait(M, passenger, sim.Points{Point0, PointA}, 4)
+	E.Transit(M, passenger, PointAC, 15)
+	// Train stopped on loop idles on station and restarts to section it enters.
+	E.Wait(M, passenger, sim.Points{PointCm, PointBC}, 6)
+	E.Transit(M, passenger, PointBC, 20)
+	E.Transit(M, freight, PointBC, 10)
+	E.Wait(M, freight, sim.Points{PointCr, PointAC}, 2)
+
+	tests := []struct {
+		name          string
+		use, expected EnergyUse
+	}{
+		{"passenger", *E.trains[1], EnergyUse{70, 5, 4}},
+		{"freight", *E.trains[2], EnergyUse{30, 2, 8}},
+		{"section AC", E.sections[0], EnergyUse{30, 0, 8}},
+		{"section BC", E.sections[1], EnergyUse{70, 0, 4}},
+		{"station A", E.stations[0], EnergyUse{0, 2, 0}},
+		{"station C", E.stations[1], EnergyUse{0, 5, 0}},
+		{"station B", E.stations[2], EnergyUse{0, 0, 0}},
+	}
+	for _, test := range tests {
+		if test.use != test.expected {
+			t.Errorf("Expected energy %+v of %s, got %+v", test.expected, test.name, test.use)
+		}
+	}
+	if total := E.Total(); total != (EnergyUse{100, 7, 12}) || total.Total() != 119 {
+		t.Errorf("Expected total energy %+v, got %+v", EnergyUse{100, 7, 12}, total)
+	}
+	if emission := E.Emission(); math.Abs(emission-(79*0.1+40*0.2)) > 1e-9 {
+		t.Errorf("Expected emission %.2f, got %.2f", 79*0.1+40*0.2, emission)
+	}
+
+	if _, err := NewEnergy(EnergyConfig{}, M); err == nil {
+		t.Errorf("Expected error of energy without train types")
+	}
+	if _, err := NewEnergy(EnergyConfig{[]EnergyRates{{Name: "x", Idling: -1}}}, M); err == nil {
+		t.Errorf("Expected error of negative rates")
+	}
+}
+
+func TestEnergyRun(t *testing.T) {
+	config := DefaultConfig()
+	config.Train = &testFreight
+	profile := ProfileConfig{Segments: []SegmentConfig{{Length: 6000, Gradient: 5, SpeedLimit: 100}, {Length: 8000, SpeedLimit: 60}}}
+	config.Profiles = map[string]ProfileConfig{"ac": profile, "bc": profile}
+	config.Energy = &EnergyConfig{testRates[:1]}
+	M, err := config.Model(48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M.KeepValues = true
+	S := simulate(M, FIFODispatcher{}, 1)
+	E, rates := M.Energy, testRates[0]
+
+	// Traction is charged for transit times of sections with penalties, idling for waiting times on stations.
+	for j, section := range M.Sections {
+		_, sum, _ := S.GetStatistic(section.Points[0])
+		if traction := E.sections[j].Traction; math.Abs(traction-rates.Traction*sum) > 1e-6 {
+			t.Errorf("Expected traction %.2f on %s, got %.2f", rates.Traction*sum, section.Name, traction)
+		}
+	}
+	idling := 0.0
+	for i, station := range M.Stations {
+		sum := 0.0
+		for _, track := range station.Tracks {
+			_, waiting, _ := S.GetStatistic(track)
+			sum += waiting
+		}
+		if math.Abs(E.stations[i].Idling-rates.Idling*sum) > 1e-6 {
+			t.Errorf("Expected idling %.2f on %s, got %.2f", rates.Idling*sum, station.Name, E.stations[i].Idling)
+		}
+		idling += E.stations[i].Idling
+	}
+
+	// Every train which departs from loop with penalty after standing on it restarts once.
+	stopped := 0
+	for j, section := range M.Sections {
+		departure := [2]int{1, 0}[j]
+		penalty := M.Penalty(sim.Points{PointCm, section.Points[0]})
+		values, _ := S.GetValues(section.Points[0])
+		for _, value := range values {
+			if math.Abs(value-section.Running[departure].Time-penalty) < 1e-6 {
+				stopped++
+			}
+		}
+	}
+	total := E.Total()
+	if stopped == 0 || math.Abs(total.Restart-rates.Restart*float64(stopped)) > 1e-6 {
+		t.Errorf("Expected restart energy %.2f of %d stopped trains, got %.2f", rates.Restart*float64(stopped), stopped, total.Restart)
+	}
+
+	// Energy of trains is split between sections and stations without loss.
+	sections := EnergyUse{}
+	for _, use := range E.sections {
+		sections.add(use)
+	}
+	if math.Abs(sections.Traction-total.Traction) > 1e-6 || math.Abs(sections.Restart-total.Restart) > 1e-6 ||
+		math.Abs(sections.Idling+idling-total.Idling) > 1e-6 {
+		t.Errorf("Expected energy of trains %+v split by sections %+v and stations idling %.2f", total, sections, idling)
+	}
+}
+
+func TestEnergyHold(t *testing.T) {
+	config := DefaultConfig()
+	config.Train = &testFreight
+	profile := ProfileConfig{Segments: []SegmentConfig{{Length: 6000, Gradient: 5, SpeedLimit: 100}, {Length: 8000, SpeedLimit: 60}}}
+	config.Profiles = map[string]ProfileConfig{"ac": profile, "bc": profile}
+	config.Energy = &EnergyConfig{testRates[:1]}
+	M, err := config.Model(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M.TimeTable[Delay] = sim.Pair{0, 0}
+	if M.Timetable, err = testTimetable(t, M, "T1,A,,60\nT1,C,70,90\nT1,B,120,\nT2,B,,150\nT2,C,175,180\nT2,A,210,\n"); err != nil {
+		t.Fatal(err)
+	}
+	M.RoadMap = TraceRoadMap(M.RoadMap, M.Timetable.Stations(M))
+	S := simulate(M, FIFODispatcher{}, 1)
+	rates := testRates[0]
+
+	// Trains run through empty line and are held at loop by timetable, they don't wait in waitlist.
+	// Standing by hold costs idling on loop, restart and stop penalty of departure like waiting for line.
+	arrivals := make(map[int]float64)
+	standing, held, expected := 0.0, 0, 0.0
+	for _, movement := range M.Journal.Movements {
+		if i, ok := M.stationIndex(movement.To); ok && i == 1 {
+			arrivals[movement.Id] = movement.Time
+		}
+		if i, ok := M.stationIndex(movement.From); ok && i == 1 {
+			if movement.Time <= arrivals[movement.Id] {
+				t.Errorf("Expected train %d held at loop, it departed at arrival %.2f", movement.Id, movement.Time)
+			}
+			standing += movement.Time - arrivals[movement.Id]
+			expected += M.Penalty(sim.Points{movement.From, movement.To})
+			held++
+		}
+	}
+	for _, section := range M.Sections {
+		expected += section.Running[0].Time + section.Running[1].Time
+	}
+	if held != 2 {
+		t.Fatalf("Expected 2 trains held at loop, got %d", held)
+	}
+	E := M.Energy
+	if math.Abs(E.stations[1].Idling-rates.Idling*standing) > 1e-6 {
+		t.Errorf("Expected idling %.2f on loop for standing %.2f, got %.2f", rates.Idling*standing, standing, E.stations[1].Idling)
+	}
+	if total := E.Total(); total.Restart != 2*rates.Restart || math.Abs(total.Idling-E.stations[1].Idling) > 1e-6 {
+		t.Errorf("Expected restart %.2f and idling only on loop, got %+v", 2*rates.Restart, total)
+	}
+	sum := 0.0
+	for _, section := range M.Sections {
+		_, transit, _ := S.GetStatistic(section.Points[0])
+		sum += transit
+	}
+	if math.Abs(sum-expected) > 1e-6 || math.Abs(E.Total().Traction-rates.Traction*sum) > 1e-6 {
+		t.Errorf("Expected transit time %.2f with penalties and traction %.2f, got %.2f and %.2f",
+			expected, rates.Traction*expected, sum, E.Total().Traction)
+	}
+}
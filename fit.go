@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"simulation-modeling/sim"
+	"simulation-modeling/statistic"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fitted distribution with goodness of fit statistics.
+type Candidate struct {
+	Distribution                  sim.Distribution
+	KS, AD, ChiSquare             float64
+	KSRank, ADRank, ChiSquareRank int
+}
+
+func ReadColumn(FileName string, Column int, Header bool) ([]float64, error) {
+	file, err := os.Open(FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	values := make([]float64, 0, 100)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if Header && line == 1 {
+			continue
+		}
+		if Column >= len(record) {
+			return nil, errors.New(fmt.Sprintf("%s:%d: no column %d", FileName, line, Column+1))
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[Column]), 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("%s:%d: %s", FileName, line, err))
+		}
+		values = append(values, value)
+	}
+	if len(values) < 2 {
+		return nil, errors.New(fmt.Sprintf("not enough observations in %s: %d", FileName, len(values)))
+	}
+	return values, nil
+}
+
+// FitDistributions returns distributions with parameters estimated from values.
+// Uniform, exponential, normal and log-normal parameters are maximum likelihood estimates,
+// triangular parameters are estimated by method of moments.
+func FitDistributions(Values []float64) []sim.Distribution {
+	min, max := Values[0], Values[0]
+	positive := true
+	for _, v := range Values {
+		min, max = math.Min(min, v), math.Max(max, v)
+		positive = positive && v > 0
+	}
+	mean := statistic.Mean(Values)
+	n := float64(len(Values))
+	deviation := math.Sqrt(statistic.Variance(Values) * (n - 1) / n)
+
+	distributions := []sim.Distribution{sim.Pair{min, max}}
+	if positive {
+		distributions = append(distributions, sim.Exp{1 / mean})
+		logs := make([]float64, len(Values))
+		for i, v := range Values {
+			logs[i] = math.Log(v)
+		}
+		if d := math.Sqrt(statistic.Variance(logs) * (n - 1) / n); d > 0 {
+			distributions = append(distributions, sim.LogNormal{statistic.Mean(logs), d})
+		}
+	}
+	if deviation > 0 {
+		distributions = append(distributions, sim.Normal{mean, deviation})
+	}
+	if min < max {
+		mode := math.Min(math.Max(3*mean-min-max, min), max)
+		distributions = append(distributions, sim.Triangular{min, mode, max})
+	}
+	return distributions
+}
+
+// RankDistributions returns candidates sorted by sum of ranks of goodness of fit statistics.
+func RankDistributions(Values []float64, Distributions []sim.Distribution, Bins int) []Candidate {
+	candidates := make([]Candidate, len(Distributions))
+	for i, d := range Distributions {
+		candidates[i] = Candidate{Distribution: d,
+			KS:        statistic.KolmogorovSmirnov(Values, d.CDF),
+			AD:        statistic.AndersonDarling(Values, d.CDF),
+			ChiSquare: statistic.ChiSquare(Values, d.CDF, Bins)}
+	}
+	rank := func(value func(Candidate) float64, set func(*Candidate, int)) {
+		sort.SliceStable(candidates, func(i, j int) bool { return value(candidates[i]) < value(candidates[j]) })
+		for i := range candidates {
+			set(&candidates[i], i+1)
+		}
+	}
+	rank(func(c Candidate) float64 { return c.ChiSquare }, func(c *Candidate, r int) { c.ChiSquareRank = r })
+	rank(func(c Candidate) float64 { return c.AD }, func(c *Candidate, r int) { c.ADRank = r })
+	rank(func(c Candidate) float64 { return c.KS }, func(c *Candidate, r int) { c.KSRank = r })
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].KSRank+candidates[i].ADRank+candidates[i].ChiSquareRank <
+			candidates[j].KSRank+candidates[j].ADRank+candidates[j].ChiSquareRank
+	})
+	return candidates
+}
+
+// SetTiming writes distribution as timing into configuration file, file is created if it doesn't exist.
+func SetTiming(FileName, Timing string, Distribution sim.Distribution) error {
+	if _, ok := TimingNames[Timing]; !ok {
+		return errors.New(fmt.Sprintf("timing must be one of station, ac, bc, delay or length, got %q", Timing))
+	}
+	config, err := LoadConfig(FileName)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if config.Timings == nil {
+		config.Timings = make(map[string]DistributionConfig)
+	}
+	config.Timings[Timing] = DistributionConfig{Distribution.Name(), Distribution.Parameters()}
+	return config.Save(FileName)
+}
+
+func Fit(Arguments []string) {
+	flags := flag.NewFlagSet("fit", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s fit [-column N] [-header] [-bins K] [-timing NAME -c FILE] DATA.csv\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	columnFlag := flags.Int("column", 1, "read observations from specified column (counting from 1)")
+	headerFlag := flags.Bool("header", false, "skip first line of file")
+	binsFlag := flags.Int("bins", 0, "set number of bins for chi-square test (default: square root of number of observations)")
+	timingFlag := flags.String("timing", "", "write best distribution as specified timing (station, ac, bc, delay or length) into configuration")
+	configFlag := flags.String("c", "", "model configuration file")
+	flags.Parse(Arguments)
+	if flags.NArg() != 1 || *columnFlag < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	values, err := ReadColumn(flags.Arg(0), *columnFlag-1, *headerFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	bins := *binsFlag
+	if bins < 1 {
+		bins = int(math.Max(5, math.Ceil(math.Sqrt(float64(len(values))))))
+	}
+	candidates := RankDistributions(values, FitDistributions(values), bins)
+
+	fmt.Printf("Observations: %d, mean: %.2f, chi-square bins: %d\n", len(values), statistic.Mean(values), bins)
+	fmt.Printf("%-12s %-28s %8s %8s %10s\n", "DISTRIBUTION", "PARAMETERS", "K-S", "A-D", "CHI-SQUARE")
+	for _, c := range candidates {
+		parameters := make([]string, 0, 3)
+		for _, p := range c.Distribution.Parameters() {
+			parameters = append(parameters, strconv.FormatFloat(p, 'f', 3, 64))
+		}
+		fmt.Printf("%-12s %-28s %8.4f %8.3f %10.2f\n", c.Distribution.Name(), strings.Join(parameters, ", "), c.KS, c.AD, c.ChiSquare)
+	}
+
+	if *timingFlag == "" {
+		return
+	}
+	if _, ok := TimingNames[*timingFlag]; !ok || *configFlag == "" {
+		fmt.Println("timing must be one of station, ac, bc, delay or length and configuration file must be specified")
+		os.Exit(2)
+	}
+	best := candidates[0].Distribution
+	if err := SetTiming(*configFlag, *timingFlag, best); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("Timing %q set to %s distribution in %s\n", *timingFlag, best.Name(), *configFlag)
+}
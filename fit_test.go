@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"simulation-modeling/sim"
+	"testing"
+)
+
+func TestReadColumn(t *testing.T) {
+	tests := []struct {
+		lines  string
+		column int
+		header bool
+		values []float64
+		valid  bool
+	}{
+		{"1\n2.5\n3\n", 0, false, []float64{1, 2.5, 3}, true},
+		{"x,y\n1, 2\n3,4\n", 1, true, []float64{2, 4}, true},
+		{"1,2\n3\n", 1, false, nil, false},
+		{"1\nabc\n", 0, false, nil, false},
+		{"1\n\"2\n", 0, false, nil, false},
+		{"x\n1\n", 0, true, nil, false},
+	}
+
+	for i, test := range tests {
+		file := filepath.Join(t.TempDir(), "data.csv")
+		if err := os.WriteFile(file, []byte(test.lines), 0644); err != nil {
+			t.Fatal(err)
+		}
+		values, err := ReadColumn(file, test.column, test.header)
+		if (err == nil) != test.valid || !reflect.DeepEqual(values, test.values) {
+			t.Errorf("Test %d: expected values %v and valid %t, got %v (%v)", i, test.values, test.valid, values, err)
+		}
+	}
+	if _, err := ReadColumn(filepath.Join(t.TempDir(), "none.csv"), 0, false); err == nil {
+		t.Errorf("Expected error of missing file")
+	}
+}
+
+func TestFitDistributions(t *testing.T) {
+	logs := []float64{0, math.Log(2), math.Log(3), math.Log(4)}
+	mean, deviation := (logs[1]+logs[2]+logs[3])/4, 0.0
+	for _, l := range logs {
+		deviation += (l - mean) * (l - mean) / 4
+	}
+	tests := []struct {
+		values        []float64
+		distributions []sim.Distribution
+	}{
+		{[]float64{1, 2, 3, 4}, []sim.Distribution{sim.Pair{1, 4}, sim.Exp{0.4}, sim.LogNormal{mean, math.Sqrt(deviation)},
+			sim.Normal{2.5, math.Sqrt(1.25)}, sim.Triangular{1, 2.5, 4}}},
+		// Exponential and log-normal distributions are fitted only to positive values.
+		{[]float64{-1, 1}, []sim.Distribution{sim.Pair{-1, 1}, sim.Normal{0, 1}, sim.Triangular{-1, 0, 1}}},
+		{[]float64{2, 2}, []sim.Distribution{sim.Pair{2, 2}, sim.Exp{0.5}}},
+	}
+
+	for i, test := range tests {
+		distributions := FitDistributions(test.values)
+		if len(distributions) != len(test.distributions) {
+			t.Fatalf("Test %d: expected distributions %v, got %v", i, test.distributions, distributions)
+		}
+		for j, d := range distributions {
+			expected := test.distributions[j]
+			if d.Name() != expected.Name() {
+				t.Errorf("Test %d: expected %s distribution, got %s", i, expected.Name(), d.Name())
+				continue
+			}
+			for k, p := range d.Parameters() {
+				if math.Abs(p-expected.Parameters()[k]) > 1e-9 {
+					t.Errorf("Test %d: expected parameters %v of %s distribution, got %v", i, expected.Parameters(), d.Name(), d.Parameters())
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestRankDistributions(t *testing.T) {
+	tests := []struct {
+		distribution sim.Distribution
+		best         string
+	}{
+		{sim.Pair{12, 18}, "uniform"},
+		{sim.Exp{0.1}, "exponential"},
+		{sim.Normal{15, 2}, "normal"},
+	}
+
+	for _, test := range tests {
+		R := sim.NewStream(1)
+		values := make([]float64, 500)
+		for i := range values {
+			values[i], _ = test.distribution.Sample(R)
+		}
+		candidates := RankDistributions(values, FitDistributions(values), 20)
+		if best := candidates[0].Distribution.Name(); best != test.best {
+			t.Errorf("Expected %s distribution ranked first, got %s", test.best, best)
+		}
+		// Each statistic ranks all candidates, candidates are ordered by sum of ranks.
+		ranks := make([]map[int]bool, 3)
+		for i := range ranks {
+			ranks[i] = make(map[int]bool)
+		}
+		for i, c := range candidates {
+			ranks[0][c.KSRank], ranks[1][c.ADRank], ranks[2][c.ChiSquareRank] = true, true, true
+			if i > 0 {
+				previous := candidates[i-1]
+				if previous.KSRank+previous.ADRank+previous.ChiSquareRank > c.KSRank+c.ADRank+c.ChiSquareRank {
+					t.Errorf("Expected candidates of %s sample ordered by sum of ranks, got %+v", test.best, candidates)
+				}
+			}
+		}
+		for _, rank := range ranks {
+			if len(rank) != len(candidates) || rank[0] || rank[len(candidates)+1] {
+				t.Errorf("Expected ranks from 1 to %d, got %v", len(candidates), rank)
+			}
+		}
+	}
+}
+
+func TestSetTiming(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		file, content, timing string
+		valid                 bool
+	}{
+		{"new.json", "", "ac", true},
+		{"null.json", "{\"timings\": null}", "station", true},
+		{"bad.json", "{", "ac", false},
+		{"new.json", "", "xx", false},
+	}
+
+	for i, test := range tests {
+		file := filepath.Join(dir, test.file)
+		if test.content != "" {
+			if err := os.WriteFile(file, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := SetTiming(file, test.timing, sim.Triangular{12, 14, 20})
+		if (err == nil) != test.valid {
+			t.Errorf("Test %d: expected valid %t, got error %v", i, test.valid, err)
+		}
+		if err != nil {
+			continue
+		}
+		// Written timing is read back into model.
+		config, err := LoadConfig(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		M, err := config.Model(24)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := M.TimeTable[TimingNames[test.timing]]; d.Name() != "triangular" || d.Mean() != 46.0/3 {
+			t.Errorf("Test %d: expected triangular timing %s with mean %.2f, got %v", i, test.timing, 46.0/3, d)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"simulation-modeling/sim"
+)
+
+// Fleet of rolling stock and crews in configuration.
+// Units and crews are placed evenly on terminal stations, train arrived at terminal is turned around
+// and can depart from it again after turnaround time, its crew after rest time. Crews aren't limited if not set.
+type FleetConfig struct {
+	Size       int                 `json:"size"`
+	Turnaround DistributionConfig  `json:"turnaround"`
+	Crews      int                 `json:"crews,omitempty"`
+	CrewRest   *DistributionConfig `json:"crew_rest,omitempty"`
+}
+
+// Fleet of simulation run: each unit is a transaction which runs between terminal stations, crews are resources
+// of terminal stations. Unit turned around waits in stock chain of terminal. Arrival at terminal is demand:
+// it sends the first unit of stock chain, train is cancelled if stock is empty. Unit is held on terminal
+// if no crew is ready when it's due to depart.
+type Fleet struct {
+	Size, Crews                   int
+	Turnaround, CrewRest          sim.Distribution
+	crews                         map[int][]float64
+	departures                    map[int]float64
+	busy, crewBusy                float64
+	departed, cancelled, uncrewed map[int]int
+	units, due, held              map[int]bool
+}
+
+func NewFleet(Config FleetConfig, M *Model) (*Fleet, error) {
+	if Config.Size < 1 {
+		return nil, errors.New(fmt.Sprintf("fleet must have at least one unit, got %d", Config.Size))
+	}
+	if Config.Crews < 0 {
+		return nil, errors.New(fmt.Sprintf("fleet can't have negative number of crews, got %d", Config.Crews))
+	}
+	turnaround, err := sim.NewDistribution(Config.Turnaround.Distribution, Config.Turnaround.Parameters)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("turnaround of fleet: %s", err))
+	}
+	var rest sim.Distribution = sim.Pair{0, 0}
+	if Config.CrewRest != nil {
+		if rest, err = sim.NewDistribution(Config.CrewRest.Distribution, Config.CrewRest.Parameters); err != nil {
+			return nil, errors.New(fmt.Sprintf("crew rest of fleet: %s", err))
+		}
+	}
+	F := &Fleet{Size: Config.Size, Crews: Config.Crews, Turnaround: turnaround, CrewRest: rest}
+	F.Reset(M)
+	return F, nil
+}
+
+// terminals returns terminal stations of model.
+func terminals(M *Model) []ModelStation {
+	var result []ModelStation
+	for _, station := range M.Stations {
+		if station.Terminal {
+			result = append(result, station)
+		}
+	}
+	return result
+}
+
+// Reset places crews on terminal stations before simulation run.
+func (F *Fleet) Reset(M *Model) {
+	stations := terminals(M)
+	F.crews = make(map[int][]float64)
+	for i := 0; i < F.Crews; i++ {
+		point := stations[i%len(stations)].Tracks[0]
+		F.crews[point] = append(F.crews[point], 0)
+	}
+	F.departures = make(map[int]float64)
+	F.busy, F.crewBusy = 0, 0
+	F.departed, F.cancelled, F.uncrewed = make(map[int]int), make(map[int]int), make(map[int]int)
+	F.units, F.due, F.held = make(map[int]bool), make(map[int]bool), make(map[int]bool)
+}
+
+// Generate creates transactions of units placed evenly on terminal stations, they join stock at start.
+func (F *Fleet) Generate(S *sim.Sim, R sim.Source, M *Model) {
+	stations := terminals(M)
+	for i := 0; i < F.Size; i++ {
+		station := stations[i%len(stations)]
+		tr, err := S.GeneratePriority(0, station.Tracks[0], station.Priority)
+		if err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+		SetLength(S, R, tr, M.TimeTable[Length])
+		F.units[sim.GetId(*tr)] = true
+	}
+}
+
+// Unit returns true if transaction is unit of fleet.
+func (F *Fleet) Unit(Tr *sim.Transaction) bool {
+	return F.units[sim.GetId(*Tr)]
+}
+
+// stockChain returns name of chain of units ready to depart from terminal station.
+func stockChain(Point int) string {
+	return fmt.Sprintf("stock %d", Point)
+}
+
+// crewChain returns name of chain of units waiting for crew on terminal station.
+func crewChain(Point int) string {
+	return fmt.Sprintf("crew %d", Point)
+}
+
+// take removes the earliest resource ready by time, it returns false if there isn't any.
+func take(Ready []float64, Time float64) ([]float64, bool) {
+	earliest := -1
+	for i, ready := range Ready {
+		if ready <= Time && (earliest < 0 || ready < Ready[earliest]) {
+			earliest = i
+		}
+	}
+	if earliest < 0 {
+		return Ready, false
+	}
+	return append(Ready[:earliest], Ready[earliest+1:]...), true
+}
+
+// Depart handles transaction due to depart from terminal station. Demand arrival sends the first unit
+// of stock and is cancelled if stock is empty, unit joins stock after turnaround. Unit sent by demand is assigned crew,
+// unit without ready crew is held: it's delayed until the earliest crew of terminal is ready or waits
+// in chain until crew arrives at terminal. It returns false if transaction doesn't depart.
+func (F *Fleet) Depart(S *sim.Sim, M *Model, Tr *sim.Transaction) bool {
+	points, id := sim.GetPoints(*Tr), sim.GetId(*Tr)
+	if _, ok := F.departures[id]; ok || points.Current != Point0 {
+		return true
+	}
+	if i, ok := M.stationIndex(points.Next); !ok || !M.Stations[i].Terminal {
+		return true
+	}
+	if !F.units[id] {
+		if len(S.GetChain(stockChain(points.Next))) == 0 {
+			F.cancelled[points.Next]++
+			return false
+		}
+		units, err := S.Unlink(stockChain(points.Next), 1)
+		if err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+		F.due[sim.GetId(*units[0])] = true
+		return false
+	}
+	if !F.due[id] {
+		S.Link(Tr, stockChain(points.Next))
+		return false
+	}
+	if F.Crews != 0 {
+		crews, ok := take(F.crews[points.Next], S.GetSimTime())
+		if !ok {
+			if !F.held[id] {
+				F.held[id] = true
+				F.uncrewed[points.Next]++
+			}
+			if len(F.crews[points.Next]) == 0 {
+				S.Link(Tr, crewChain(points.Next))
+				return false
+			}
+			ready := F.crews[points.Next][0]
+			for _, crew := range F.crews[points.Next] {
+				ready = math.Min(ready, crew)
+			}
+			if err := S.Delay(Tr, ready-S.GetSimTime()); err != nil {
+				fmt.Println(err, S.DebugString())
+				os.Exit(1)
+			}
+			return false
+		}
+		F.crews[points.Next] = crews
+		delete(F.held, id)
+	}
+	delete(F.due, id)
+	F.departures[id] = S.GetSimTime()
+	F.departed[points.Next]++
+	return true
+}
+
+// Arrive turns around unit arrived at terminal station: it joins stock of the terminal after turnaround time,
+// its crew is ready after rest time. It returns true if transaction is turned around.
+func (F *Fleet) Arrive(S *sim.Sim, R sim.Source, M *Model, Tr *sim.Transaction) bool {
+	points := sim.GetPoints(*Tr)
+	departure, ok := F.departures[sim.GetId(*Tr)]
+	if !ok || points.Next != Point0 {
+		return false
+	}
+	delete(F.departures, sim.GetId(*Tr))
+	i, _ := M.stationIndex(points.Current)
+	terminal := M.Stations[i].Tracks[0]
+	turnaround, err := F.Turnaround.Sample(R)
+	if err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	F.busy += S.GetSimTime() + turnaround - departure
+	S.Advance(Tr, turnaround, terminal)
+	if err := S.Delay(Tr, 0); err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	if F.Crews == 0 {
+		return true
+	}
+	rest, err := F.CrewRest.Sample(R)
+	if err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	F.crews[terminal] = append(F.crews[terminal], S.GetSimTime()+rest)
+	F.crewBusy += S.GetSimTime() - departure
+	// Unit waiting for crew departs when arrived crew is ready.
+	if len(S.GetChain(crewChain(terminal))) != 0 {
+		if _, err := S.Unlink(crewChain(terminal), 1); err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+	}
+	return true
+}
+
+func WriteFleetReport(Writer *bufio.Writer, F *Fleet, M *Model, Duration float64) {
+	WriteData(Writer, fmt.Sprintf("Fleet utilization: %.2f (%d units)\n", F.busy/(Duration*60*float64(F.Size)), F.Size))
+	if F.Crews != 0 {
+		WriteData(Writer, fmt.Sprintf("Crew utilization: %.2f (%d crews)\n", F.crewBusy/(Duration*60*float64(F.Crews)), F.Crews))
+	}
+	for _, station := range M.Stations {
+		if !station.Terminal {
+			continue
+		}
+		WriteData(Writer, fmt.Sprintf("Trains departed from %s: %d, cancelled for lack of stock: %d",
+			StationLabel(station), F.departed[station.Tracks[0]], F.cancelled[station.Tracks[0]]))
+		if F.Crews != 0 {
+			WriteData(Writer, fmt.Sprintf(", %d units held for lack of crew", F.uncrewed[station.Tracks[0]]))
+		}
+		WriteData(Writer, "\n")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var testTurnaround = DistributionConfig{"uniform", []float64{20, 30}}
+
+// testFleet builds crossing loop with fleet by configuration.
+func testFleet(t *testing.T, Config FleetConfig, Hours float64) *Model {
+	config := DefaultConfig()
+	config.Fleet = &Config
+	M, err := config.Model(Hours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return M
+}
+
+func TestNewFleet(t *testing.T) {
+	M := testModel(t, nil, 24)
+	tests := []struct {
+		config FleetConfig
+		err    string
+	}{
+		{FleetConfig{Size: 2, Turnaround: testTurnaround, Crews: 1}, ""},
+		{FleetConfig{Size: 0, Turnaround: testTurnaround}, "at least one unit"},
+		{FleetConfig{Size: 2, Turnaround: testTurnaround, Crews: -1}, "negative number of crews"},
+		{FleetConfig{Size: 2, Turnaround: DistributionConfig{"uniform", []float64{30, 20}}}, "turnaround"},
+		{FleetConfig{Size: 2, Turnaround: testTurnaround, Crews: 1, CrewRest: &DistributionConfig{"x", nil}}, "crew rest"},
+	}
+
+	for i, test := range tests {
+		_, err := NewFleet(test.config, M)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("Test %d: expected error %q, got %v", i, test.err, err)
+		}
+	}
+}
+
+func TestFleetTurnaround(t *testing.T) {
+	M := testFleet(t, FleetConfig{Size: 3, Turnaround: testTurnaround}, 24)
+	S := simulate(M, FIFODispatcher{}, 1)
+
+	// Units are the only trains: arrived train departs again from the same terminal after turnaround.
+	entries, arrivals := make(map[int]int), make(map[int]Movement)
+	for _, movement := range M.Journal.Movements {
+		if movement.From == Point0 {
+			if arrival, ok := arrivals[movement.Id]; ok {
+				if movement.To != arrival.To || movement.Time < arrival.Time+20 {
+					t.Errorf("Expected train %d to depart from %d after turnaround since %.2f, got %d at %.2f",
+						movement.Id, arrival.To, arrival.Time, movement.To, movement.Time)
+				}
+			}
+			entries[movement.Id]++
+		}
+		if movement.Next == Point0 {
+			arrivals[movement.Id] = movement
+		}
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 units running, got %d: %v", len(entries), entries)
+	}
+	departed := 0
+	for id, count := range entries {
+		if count < 10 {
+			t.Errorf("Expected unit %d to run many times, got %d", id, count)
+		}
+		departed += count
+	}
+
+	F := M.Fleet
+	total := 0
+	for _, count := range F.departed {
+		total += count
+	}
+	if total < departed || total > departed+F.Size {
+		t.Errorf("Expected %d departures of units which entered line, got %d", departed, total)
+	}
+	if utilization := F.busy / (S.GetSimTime() * float64(F.Size)); utilization <= 0.5 || utilization > 1 {
+		t.Errorf("Expected units busy most of time, got utilization %.2f", utilization)
+	}
+}
+
+func TestFleetCancellations(t *testing.T) {
+	tests := []struct {
+		size      int
+		cancelled bool
+	}{
+		{1, true},
+		{8, false},
+	}
+
+	for _, test := range tests {
+		M := testFleet(t, FleetConfig{Size: test.size, Turnaround: testTurnaround}, 24)
+		S := simulate(M, FIFODispatcher{}, 1)
+		F := M.Fleet
+
+		// Each arrival at terminal sends unit or is cancelled, the last arrival of each terminal is pending.
+		arrivals, _ := S.GetCount(Point0)
+		handled, cancelled := 0, 0
+		for _, station := range terminals(M) {
+			handled += F.departed[station.Tracks[0]] + F.cancelled[station.Tracks[0]]
+			cancelled += F.cancelled[station.Tracks[0]]
+			if test.cancelled && F.cancelled[station.Tracks[0]] == 0 {
+				t.Errorf("Expected trains cancelled on %s with %d units", station.Name, test.size)
+			}
+		}
+		if !test.cancelled && cancelled != 0 {
+			t.Errorf("Expected no trains cancelled with %d units, got %d", test.size, cancelled)
+		}
+		if handled > arrivals-2 || handled < arrivals-2-test.size {
+			t.Errorf("Expected %d arrivals with departures and cancellations, got %d", arrivals-2, handled)
+		}
+		ids := make(map[int]bool)
+		for _, movement := range M.Journal.Movements {
+			if movement.From == Point0 {
+				ids[movement.Id] = true
+			}
+		}
+		if len(ids) > test.size {
+			t.Errorf("Expected only %d units to run, got %d", test.size, len(ids))
+		}
+
+		var buffer bytes.Buffer
+		writer := bufio.NewWriter(&buffer)
+		WriteFleetReport(writer, F, M, 24)
+		writer.Flush()
+		if line := fmt.Sprintf("cancelled for lack of stock: %d\n", F.cancelled[PointA]); !strings.Contains(buffer.String(), line) {
+			t.Errorf("Expected %q in report %q", line, buffer.String())
+		}
+	}
+}
+
+func TestFleetCrews(t *testing.T) {
+	rest := DistributionConfig{"uniform", []float64{30, 30}}
+	M := testFleet(t, FleetConfig{Size: 4, Turnaround: testTurnaround, Crews: 1, CrewRest: &rest}, 24)
+	S := simulate(M, FIFODispatcher{}, 1)
+
+	// Single crew runs one train at a time, units on other terminal wait for it in chain.
+	running, terminals := 0, make(map[int]int)
+	var last Movement
+	for _, movement := range M.Journal.Movements {
+		if movement.From == Point0 {
+			running++
+			terminals[movement.To]++
+			if running > 1 {
+				t.Fatalf("Expected one train on line with single crew, train %d entered at %.2f", movement.Id, movement.Time)
+			}
+			if last.Next == Point0 && movement.Time < last.Time+30 {
+				t.Errorf("Expected train %d to depart after crew rest since %.2f, got %.2f", movement.Id, last.Time, movement.Time)
+			}
+		}
+		if movement.Next == Point0 {
+			running--
+			last = movement
+		}
+	}
+	if len(terminals) != 2 || terminals[PointA] < 10 || terminals[PointB] < 10 {
+		t.Errorf("Expected trains to depart from both terminals, got %v", terminals)
+	}
+	F := M.Fleet
+	if F.uncrewed[PointA] == 0 || F.uncrewed[PointB] == 0 {
+		t.Errorf("Expected units held for lack of crew on both terminals, got %v", F.uncrewed)
+	}
+	if utilization := F.crewBusy / S.GetSimTime(); utilization <= 0 || utilization > 1 {
+		t.Errorf("Expected crew utilization in (0, 1], got %.2f", utilization)
+	}
+}
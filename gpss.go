@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"simulation-modeling/gpss"
+	"time"
+)
+
+// GPSS runs model written in GPSS block statements and writes standard report after each START statement.
+func GPSS(Arguments []string) {
+	flags := flag.NewFlagSet("gpss", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s gpss [-s SEED] [-o FILE] MODEL\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	seedFlag := flags.Int64("s", 0, "set seed of random stream (default: current time)")
+	outputFlag := flags.String("o", "", "write report to file (default: standard output)")
+	flags.Parse(Arguments)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	program, err := gpss.Parse(flags.Arg(0), file)
+	file.Close()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	model, err := gpss.NewModel(program, NewStream(seed, 0, false))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	output := os.Stdout
+	if *outputFlag != "" {
+		if output, err = os.Create(*outputFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer output.Close()
+	}
+	for _, count := range program.Starts {
+		if err := model.Run(count); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := gpss.WriteReport(output, model); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
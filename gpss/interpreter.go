@@ -0,0 +1,483 @@
+package gpss
+
+import (
+	"fmt"
+	"simulation-modeling/sim"
+	"sort"
+	"strconv"
+)
+
+// State of transaction in model: index of block which it's in and time of creation.
+type xact struct {
+	tr    *sim.Transaction
+	block int
+	next  int
+	mark  float64
+	// Transaction is blocked by TRANSFER BOTH and waits for any of its targets.
+	both bool
+}
+
+// Facility is single server seized by one transaction. It's point of simulator, owner is 0 if it's free.
+type facility struct {
+	name     string
+	point    int
+	owner    int
+	entries  int
+	seizedAt float64
+}
+
+// Storage is server with capacity shared by transactions.
+type storage struct {
+	name     string
+	capacity int
+	content  int
+	max      int
+	entries  int
+	integral float64
+	changed  float64
+}
+
+// Model is interpreter of program: blocks are executed by transactions of simulator, queues are queues of simulator.
+type Model struct {
+	P          *Program
+	S          *sim.Sim
+	R          sim.Source
+	Facilities []*facility
+	Storages   []*storage
+	// Entry and current counts of blocks.
+	Total, Current []int
+	// Number of transactions generated by GENERATE blocks.
+	generated  map[int]int
+	facilities map[string]*facility
+	storages   map[string]*storage
+	xacts      map[int]*xact
+	// Number of transactions linked to user chains.
+	links map[string]int
+	count int
+}
+
+// NewModel returns model of program with new simulator. Facilities are points of simulator in order of
+// their first reference in program.
+func NewModel(P *Program, R sim.Source) (*Model, error) {
+	m := &Model{P: P, R: R, Total: make([]int, len(P.Blocks)), Current: make([]int, len(P.Blocks)),
+		generated: make(map[int]int), facilities: make(map[string]*facility), storages: make(map[string]*storage),
+		xacts: make(map[int]*xact), links: make(map[string]int)}
+	for _, block := range P.Blocks {
+		if block.Type == "SEIZE" || block.Type == "RELEASE" {
+			m.facility(block.Names[0])
+		}
+		for _, operand := range block.Operands {
+			if operand != nil && (operand.Type == Facility || operand.Type == Captures) {
+				m.facility(operand.Name)
+			}
+		}
+	}
+	names := make([]string, 0, len(P.Storages))
+	for name := range P.Storages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := &storage{name: name, capacity: P.Storages[name]}
+		m.Storages = append(m.Storages, s)
+		m.storages[name] = s
+	}
+	m.S = sim.New(len(m.Facilities))
+	m.S.Init()
+	for i, block := range P.Blocks {
+		if block.Type == "GENERATE" {
+			if err := m.generate(i); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) facility(name string) *facility {
+	f, ok := m.facilities[name]
+	if !ok {
+		f = &facility{name: name, point: len(m.Facilities)}
+		m.Facilities = append(m.Facilities, f)
+		m.facilities[name] = f
+	}
+	return f
+}
+
+// fail returns runtime error at position of block.
+func (m *Model) fail(index int, format string, a ...interface{}) error {
+	block := m.P.Blocks[index]
+	return &Error{m.P.Name, block.Line, block.Column, fmt.Sprintf(format+" at %.3f", append(a, m.S.GetSimTime())...)}
+}
+
+// spread returns value uniformly distributed in mean±spread.
+func (m *Model) spread(mean, spread float64) float64 {
+	if spread == 0 {
+		return mean
+	}
+	return mean - spread + 2*spread*m.R.Float64()
+}
+
+// value returns value of operand for transaction, missing operand has default value.
+func (m *Model) value(x *xact, operand *SNA, fallback float64) float64 {
+	if operand == nil {
+		return fallback
+	}
+	switch operand.Type {
+	case Constant:
+		return operand.Value
+	case Clock:
+		return m.S.GetSimTime()
+	case Transit:
+		return m.S.GetSimTime() - x.mark
+	case Priority:
+		return float64(sim.GetPriority(*x.tr))
+	case BlockTotal:
+		return float64(m.Total[m.P.Labels[operand.Name]])
+	case BlockCount:
+		return float64(m.Current[m.P.Labels[operand.Name]])
+	case Facility:
+		if m.facilities[operand.Name].owner != 0 {
+			return 1
+		}
+		return 0
+	case Captures:
+		return float64(m.facilities[operand.Name].entries)
+	case Content:
+		return float64(m.S.GetQueueStatistic(operand.Name).Content)
+	case MaxContent:
+		return float64(m.S.GetQueueStatistic(operand.Name).Max)
+	case Entries:
+		return float64(m.S.GetQueueStatistic(operand.Name).Entries)
+	case Storage:
+		return float64(m.storages[operand.Name].content)
+	case Remaining:
+		s := m.storages[operand.Name]
+		return float64(s.capacity - s.content)
+	}
+	return 0
+}
+
+// generate schedules next transaction of GENERATE block unless limit count is reached.
+func (m *Model) generate(index int) error {
+	block := m.P.Blocks[index]
+	if limit := block.Operands[3]; limit != nil && float64(m.generated[index]) >= limit.Value {
+		return nil
+	}
+	time := m.spread(m.value(nil, block.Operands[0], 0), m.value(nil, block.Operands[1], 0))
+	if offset := block.Operands[2]; offset != nil && m.generated[index] == 0 {
+		time = offset.Value
+	}
+	if time < 0 {
+		return m.fail(index, "negative interval of GENERATE")
+	}
+	m.generated[index]++
+	tr, err := m.S.GeneratePriority(time, 0, int(m.value(nil, block.Operands[4], 0)))
+	if err != nil {
+		return err
+	}
+	m.xacts[sim.GetId(*tr)] = &xact{tr: tr, block: -1, next: index}
+	return nil
+}
+
+// test returns result of relation of TEST block for transaction.
+func (m *Model) test(x *xact, index int) bool {
+	block := m.P.Blocks[index]
+	a, b := m.value(x, block.Operands[0], 0), m.value(x, block.Operands[1], 0)
+	switch block.Relation {
+	case "L":
+		return a < b
+	case "LE":
+		return a <= b
+	case "E":
+		return a == b
+	case "NE":
+		return a != b
+	case "G":
+		return a > b
+	}
+	return a >= b
+}
+
+// admits returns true if transaction can enter block.
+func (m *Model) admits(x *xact, index int) bool {
+	block := m.P.Blocks[index]
+	switch block.Type {
+	case "SEIZE":
+		return m.facilities[block.Names[0]].owner == 0
+	case "ENTER":
+		s := m.storages[block.Names[0]]
+		return s.capacity-s.content >= int(m.value(x, block.Operands[1], 1))
+	case "TEST":
+		return block.Targets[2] >= 0 || m.test(x, index)
+	}
+	return true
+}
+
+// waits returns true if blocked transaction can't move yet.
+func (m *Model) waits(x *xact) bool {
+	if x.both {
+		block := m.P.Blocks[x.block]
+		return !m.admits(x, block.Targets[1]) && !m.admits(x, block.Targets[2])
+	}
+	return !m.admits(x, x.next)
+}
+
+// integrate adds time integral of content of storage until current time.
+func integrate(integral *float64, changed *float64, content int, now float64) {
+	*integral += float64(content) * (now - *changed)
+	*changed = now
+}
+
+// move moves transaction through blocks until it's delayed, blocked or terminated.
+func (m *Model) move(x *xact) error {
+	now := m.S.GetSimTime()
+	for {
+		if x.both {
+			if m.waits(x) {
+				m.S.AddToWaitlist(x.tr)
+				return nil
+			}
+			block := m.P.Blocks[x.block]
+			if x.next = block.Targets[1]; !m.admits(x, x.next) {
+				x.next = block.Targets[2]
+			}
+			x.both = false
+		}
+		index := x.next
+		if index >= len(m.P.Blocks) {
+			return m.fail(x.block, "transaction %d has no next block", sim.GetId(*x.tr))
+		}
+		if !m.admits(x, index) {
+			return m.block(x, index)
+		}
+		block := m.P.Blocks[index]
+		if block.Type == "GENERATE" && x.block >= 0 {
+			return m.fail(index, "transaction %d can't enter GENERATE", sim.GetId(*x.tr))
+		}
+		if x.block >= 0 {
+			m.Current[x.block]--
+		}
+		m.Total[index]++
+		m.Current[index]++
+		x.block, x.next = index, index+1
+		switch block.Type {
+		case "GENERATE":
+			x.mark = now
+			if err := m.generate(index); err != nil {
+				return err
+			}
+		case "ADVANCE":
+			time := m.spread(m.value(x, block.Operands[0], 0), m.value(x, block.Operands[1], 0))
+			if time < 0 {
+				return m.fail(index, "negative time of ADVANCE")
+			}
+			return m.S.Delay(x.tr, now-sim.GetTime(*x.tr)+time)
+		case "SEIZE":
+			f := m.facilities[block.Names[0]]
+			if err := m.S.SeizePoint(f.point); err != nil {
+				return err
+			}
+			f.owner, f.seizedAt = sim.GetId(*x.tr), now
+			f.entries++
+		case "RELEASE":
+			f := m.facilities[block.Names[0]]
+			if f.owner != sim.GetId(*x.tr) {
+				return m.fail(index, "facility %s isn't seized by transaction %d", f.name, sim.GetId(*x.tr))
+			}
+			if err := m.S.ReleasePoint(f.point); err != nil {
+				return err
+			}
+			if err := m.S.AddStatistic(f.point, now-f.seizedAt); err != nil {
+				return err
+			}
+			f.owner = 0
+		case "QUEUE":
+			if m.S.InQueue(x.tr, block.Names[0]) {
+				return m.fail(index, "transaction %d is already in queue %s", sim.GetId(*x.tr), block.Names[0])
+			}
+			if err := m.S.Join(x.tr, block.Names[0], int(m.value(x, block.Operands[1], 1))); err != nil {
+				return m.fail(index, "%s", err)
+			}
+		case "DEPART":
+			// Units of QUEUE block are removed from queue.
+			if !m.S.InQueue(x.tr, block.Names[0]) {
+				return m.fail(index, "transaction %d isn't in queue %s", sim.GetId(*x.tr), block.Names[0])
+			}
+			if _, err := m.S.Depart(x.tr, block.Names[0]); err != nil {
+				return err
+			}
+		case "ENTER":
+			s := m.storages[block.Names[0]]
+			integrate(&s.integral, &s.changed, s.content, now)
+			s.content += int(m.value(x, block.Operands[1], 1))
+			s.entries++
+			if s.content > s.max {
+				s.max = s.content
+			}
+		case "LEAVE":
+			s := m.storages[block.Names[0]]
+			units := int(m.value(x, block.Operands[1], 1))
+			if s.content < units {
+				return m.fail(index, "storage %s has less than %d units", s.name, units)
+			}
+			integrate(&s.integral, &s.changed, s.content, now)
+			s.content -= units
+			if _, err := m.S.Unlink(storageChain(s.name), 0); err != nil {
+				return err
+			}
+		case "TEST":
+			if !m.test(x, index) {
+				x.next = block.Targets[2]
+			}
+		case "TRANSFER":
+			switch {
+			case block.Names[0] == "BOTH":
+				x.both = true
+			case block.Operands[0] == nil:
+				x.next = block.Targets[1]
+			case m.R.Float64() < block.Operands[0].Value:
+				x.next = block.Targets[2]
+			case block.Targets[1] >= 0:
+				x.next = block.Targets[1]
+			}
+		case "LINK":
+			if order := block.Names[1]; order != "" {
+				if err := m.S.SetChainOrder(block.Names[0], chainOrders[order]); err != nil {
+					return err
+				}
+			}
+			m.S.Link(x.tr, block.Names[0])
+			m.links[block.Names[0]]++
+			return nil
+		case "UNLINK":
+			count := 0
+			if block.Names[2] != "ALL" {
+				count = int(m.value(x, block.Operands[2], 0))
+			}
+			unlinked, err := m.S.Unlink(block.Names[0], count)
+			if err != nil {
+				return err
+			}
+			for _, tr := range unlinked {
+				m.xacts[sim.GetId(*tr)].next = block.Targets[1]
+			}
+		case "SPLIT":
+			children, err := m.S.Split(x.tr, int(m.value(x, block.Operands[0], 0)), 0, 0)
+			if err != nil {
+				return m.fail(index, "%s", err)
+			}
+			for _, tr := range children {
+				m.xacts[sim.GetId(*tr)] = &xact{tr: tr, block: index, next: block.Targets[1], mark: x.mark}
+				m.Current[index]++
+			}
+		case "ASSEMBLE":
+			_, disposed, err := m.S.Assemble(x.tr, strconv.Itoa(index), int(m.value(x, block.Operands[0], 0)))
+			if err != nil {
+				return m.fail(index, "%s", err)
+			}
+			// Held transaction continues from next block when assembly is complete.
+			if disposed {
+				m.Current[index]--
+				delete(m.xacts, sim.GetId(*x.tr))
+			}
+			return nil
+		case "TERMINATE":
+			m.Current[index]--
+			m.S.Dispose(x.tr)
+			delete(m.xacts, sim.GetId(*x.tr))
+			if m.count -= int(m.value(x, block.Operands[0], 0)); m.count <= 0 {
+				m.S.Terminate()
+			}
+			return nil
+		}
+	}
+}
+
+// storageChain returns name of chain of transactions waiting for storage, it differs from names of user chains.
+func storageChain(name string) string {
+	return "STORAGE " + name
+}
+
+// block makes transaction wait until it can enter block. Transactions waiting for facility or storage are
+// linked to its chain and they try again after release, others are in waitlist checked after each phase.
+func (m *Model) block(x *xact, index int) error {
+	block := m.P.Blocks[index]
+	switch {
+	case block.Type == "SEIZE":
+		return m.S.WaitFor(x.tr, m.facilities[block.Names[0]].point)
+	case block.Type == "ENTER":
+		m.S.Link(x.tr, storageChain(block.Names[0]))
+		return nil
+	}
+	m.S.AddToWaitlist(x.tr)
+	return nil
+}
+
+// scan moves blocked transactions which can enter their next blocks, in order of priority.
+// Scan is repeated while any transaction moves, because it changes state of model.
+func (m *Model) scan() error {
+	for moved := true; moved && m.count > 0; {
+		moved = false
+		waiting := append([]*sim.Transaction(nil), m.S.GetWaitlist()...)
+		sort.SliceStable(waiting, func(i, j int) bool { return sim.GetPriority(*waiting[i]) > sim.GetPriority(*waiting[j]) })
+		for _, tr := range waiting {
+			x := m.xacts[sim.GetId(*tr)]
+			if m.waits(x) {
+				continue
+			}
+			m.S.RemoveFromWaitlist(tr)
+			if err := m.move(x); err != nil {
+				return err
+			}
+			moved = true
+			if m.count <= 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Run runs simulation until termination count of START statement is decremented to zero by TERMINATE blocks.
+// Following START statements continue simulation from its state.
+func (m *Model) Run(Count int) error {
+	m.count = Count
+	for m.count > 0 {
+		if len(m.S.GetFuture()) == 0 {
+			return &Error{m.P.Name, 1, 1, fmt.Sprintf("no transactions in future events chain at %.3f", m.S.GetSimTime())}
+		}
+		cec, err := m.S.Extraction()
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(cec, func(i, j int) bool { return sim.GetPriority(*cec[i]) > sim.GetPriority(*cec[j]) })
+		for i, tr := range cec {
+			if m.count <= 0 {
+				// Rest of current events chain is kept for next START statement.
+				for _, tr := range cec[i:] {
+					if err := m.S.Delay(tr, 0); err != nil {
+						return err
+					}
+				}
+				break
+			}
+			if err := m.move(m.xacts[sim.GetId(*tr)]); err != nil {
+				return err
+			}
+		}
+		if err := m.scan(); err != nil {
+			return err
+		}
+	}
+	m.integrate()
+	return nil
+}
+
+// integrate brings time integrals of storages up to current time.
+func (m *Model) integrate() {
+	now := m.S.GetSimTime()
+	for _, s := range m.Storages {
+		integrate(&s.integral, &s.changed, s.content, now)
+	}
+}
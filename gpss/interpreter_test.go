@@ -0,0 +1,157 @@
+package gpss
+
+import (
+	"bytes"
+	"reflect"
+	"simulation-modeling/sim"
+	"strings"
+	"testing"
+)
+
+func TestModel(t *testing.T) {
+	// Customers arrive every 10 minutes and are served for 15 minutes, simulation ends at 95.
+	source := `
+        GENERATE  10
+        QUEUE     WAIT
+        SEIZE     SERVER
+        DEPART    WAIT
+        ADVANCE   15
+        RELEASE   SERVER
+        TERMINATE
+        GENERATE  95
+        TERMINATE 1
+        START     1
+`
+	p, err := Parse("test", strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewModel(p, sim.NewStream(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Run(p.Starts[0]); err != nil {
+		t.Fatal(err)
+	}
+	if m.S.GetSimTime() != 95 {
+		t.Errorf("Expected clock 95, got %f", m.S.GetSimTime())
+	}
+	if expected := []int{9, 9, 6, 6, 6, 5, 5, 1, 1}; !reflect.DeepEqual(m.Total, expected) {
+		t.Errorf("Expected entry counts %v, got %v", expected, m.Total)
+	}
+	if expected := []int{0, 3, 0, 0, 1, 0, 0, 0, 0}; !reflect.DeepEqual(m.Current, expected) {
+		t.Errorf("Expected current counts %v, got %v", expected, m.Current)
+	}
+	if f := m.Facilities[0]; f.entries != 6 || f.owner == 0 {
+		t.Errorf("Expected 6 entries of seized facility, got %d and owner %d", f.entries, f.owner)
+	}
+	if q := m.S.GetQueueStatistic("WAIT"); q.Content != 3 || q.Entries != 9 || q.ZeroEntries != 1 || q.AverageNonZero != 15 {
+		t.Errorf("Expected content 3, 9 entries, 1 zero entry and average time 15 excluding it, got %+v", q)
+	}
+	var report bytes.Buffer
+	if err := WriteReport(&report, m); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "SERVER              6    0.895     14.167      7") {
+		t.Errorf("Incorrect report:\n%s", report.String())
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	p, err := Parse("test", strings.NewReader(" GENERATE 10\n RELEASE X\n TERMINATE 1\n START 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewModel(p, sim.NewStream(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Run(1); err == nil || err.Error() != "test:2:2: facility X isn't seized by transaction 1 at 10.000" {
+		t.Errorf("Expected error of RELEASE, got %v", err)
+	}
+}
+
+func TestLink(t *testing.T) {
+	// Customers wait in user chain when server is busy, release unlinks the last of them.
+	source := `
+        GENERATE  10,,5
+        TEST E    F$SERVER,0,WAIT
+SERVE   SEIZE     SERVER
+        ADVANCE   13
+        RELEASE   SERVER
+        UNLINK    LINE,SERVE,1
+        TERMINATE
+WAIT    LINK      LINE,LIFO
+        GENERATE  100
+        TERMINATE 1
+        START     1
+`
+	p, err := Parse("test", strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewModel(p, sim.NewStream(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Run(p.Starts[0]); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{10, 10, 8, 8, 7, 7, 7, 9, 1, 1}; !reflect.DeepEqual(m.Total, expected) {
+		t.Errorf("Expected entry counts %v, got %v", expected, m.Total)
+	}
+	var marks []float64
+	for _, tr := range m.S.GetChain("LINE") {
+		marks = append(marks, m.xacts[sim.GetId(*tr)].mark)
+	}
+	if expected := []float64{85, 45}; !reflect.DeepEqual(marks, expected) {
+		t.Errorf("Expected customers arrived at %v in chain, got %v", expected, marks)
+	}
+	if m.links["LINE"] != 9 {
+		t.Errorf("Expected 9 entries of chain, got %d", m.links["LINE"])
+	}
+}
+
+func TestSplit(t *testing.T) {
+	// Train is split into two portions which run for 20 and 30 minutes and are coupled again.
+	source := `
+        GENERATE  60
+        SPLIT     1,PORTION
+        ADVANCE   20
+        TRANSFER  ,JOIN
+PORTION ADVANCE   30
+JOIN    ASSEMBLE  2
+        ADVANCE   5
+        TERMINATE
+        GENERATE  190
+        TERMINATE 1
+        START     1
+`
+	p, err := Parse("test", strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewModel(p, sim.NewStream(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Run(p.Starts[0]); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{3, 3, 3, 2, 3, 4, 2, 2, 1, 1}; !reflect.DeepEqual(m.Total, expected) {
+		t.Errorf("Expected entry counts %v, got %v", expected, m.Total)
+	}
+	if expected := []int{0, 0, 1, 0, 1, 0, 0, 0, 0, 0}; !reflect.DeepEqual(m.Current, expected) {
+		t.Errorf("Expected current counts %v, got %v", expected, m.Current)
+	}
+	families := m.S.GetFamilies()
+	if len(families) != 3 {
+		t.Fatalf("Expected 3 families, got %v", families)
+	}
+	for i, id := range families {
+		f, _ := m.S.GetFamilyStatistic(id)
+		if i < 2 && (f.Alive != 0 || f.Lifetime != 35 || f.MemberLifetime != 32.5) || i == 2 && (f.Alive != 2 || f.Lifetime != 10) {
+			t.Errorf("Incorrect statistic of family %d: %+v", i+1, f)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Package gpss implements parser and interpreter of subset of GPSS block statements on top of simulator.
+package gpss
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"simulation-modeling/sim"
+	"strconv"
+	"strings"
+)
+
+// Error of model with position in source, column is counted from 1.
+type Error struct {
+	Name         string
+	Line, Column int
+	Message      string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Name, e.Line, e.Column, e.Message)
+}
+
+// Types of standard numerical attributes.
+const (
+	Constant   = iota
+	Clock      // AC1, C1
+	Transit    // M1
+	Priority   // PR
+	BlockTotal // N$label
+	BlockCount // W$label
+	Facility   // F$name
+	Captures   // FC$name
+	Content    // Q$name
+	MaxContent // QM$name
+	Entries    // QC$name
+	Storage    // S$name
+	Remaining  // R$name
+)
+
+var attributes = map[string]int{"N": BlockTotal, "W": BlockCount, "F": Facility, "FC": Captures,
+	"Q": Content, "QM": MaxContent, "QC": Entries, "S": Storage, "R": Remaining}
+
+// Standard numerical attribute: constant value or attribute of entity by name.
+type SNA struct {
+	Type  int
+	Name  string
+	Value float64
+}
+
+// Block statement. Names of entities and labels are in Names, numbers and attributes are in Operands,
+// missing operands are nil.
+type Block struct {
+	Line, Column int
+	Label        string
+	Type         string
+	Relation     string
+	Names        []string
+	Operands     []*SNA
+	// Indices of target blocks of TEST and TRANSFER, -1 if not set.
+	Targets []int
+}
+
+// Program is parsed model: blocks, capacities of storages and termination counts of START statements.
+type Program struct {
+	Name     string
+	Blocks   []*Block
+	Labels   map[string]int
+	Storages map[string]int
+	Starts   []int
+}
+
+// Number of operands of blocks and positions of names of entities and labels in operands.
+var blockOperands = map[string]struct {
+	count  int
+	names  []int
+	labels []int
+}{
+	"GENERATE":  {5, nil, nil},
+	"ADVANCE":   {2, nil, nil},
+	"SEIZE":     {1, []int{0}, nil},
+	"RELEASE":   {1, []int{0}, nil},
+	"QUEUE":     {2, []int{0}, nil},
+	"DEPART":    {2, []int{0}, nil},
+	"ENTER":     {2, []int{0}, nil},
+	"LEAVE":     {2, []int{0}, nil},
+	"TEST":      {3, nil, []int{2}},
+	"TRANSFER":  {3, nil, []int{1, 2}},
+	"TERMINATE": {1, nil, nil},
+	"LINK":      {2, []int{0, 1}, nil},
+	"SPLIT":     {2, nil, []int{1}},
+	"ASSEMBLE":  {1, nil, nil},
+	"UNLINK":    {3, []int{0}, []int{1}},
+}
+
+var chainOrders = map[string]int{"FIFO": sim.FIFO, "LIFO": sim.LIFO, "PR": sim.ByPriority}
+
+var relations = map[string]bool{"L": true, "LE": true, "E": true, "NE": true, "G": true, "GE": true}
+
+// Token of line with its column.
+type token struct {
+	text   string
+	column int
+}
+
+// tokens splits line into tokens separated by white space, comment after semicolon is dropped.
+func tokens(line string) []token {
+	var result []token
+	start := -1
+	for i, c := range line + " " {
+		if c == ';' {
+			line = line[:i]
+		}
+		if i >= len(line) || c == ' ' || c == '\t' {
+			if start >= 0 {
+				result = append(result, token{line[start:i], start + 1})
+				start = -1
+			}
+			if i >= len(line) {
+				break
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	return result
+}
+
+// operands splits operand field by commas.
+func operands(field token) []token {
+	var result []token
+	column := field.column
+	for _, text := range strings.Split(field.text, ",") {
+		result = append(result, token{text, column})
+		column += len(text) + 1
+	}
+	return result
+}
+
+func (p *Program) fail(line, column int, format string, a ...interface{}) error {
+	return &Error{p.Name, line, column, fmt.Sprintf(format, a...)}
+}
+
+// sna parses standard numerical attribute.
+func (p *Program) sna(line int, operand token) (*SNA, error) {
+	text := operand.text
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return &SNA{Constant, "", value}, nil
+	}
+	switch strings.ToUpper(text) {
+	case "AC1", "C1":
+		return &SNA{Type: Clock}, nil
+	case "M1":
+		return &SNA{Type: Transit}, nil
+	case "PR":
+		return &SNA{Type: Priority}, nil
+	}
+	if i := strings.Index(text, "$"); i > 0 && i < len(text)-1 {
+		if kind, ok := attributes[strings.ToUpper(text[:i])]; ok {
+			return &SNA{Type: kind, Name: text[i+1:]}, nil
+		}
+	}
+	return nil, p.fail(line, operand.column, "unknown operand %q", text)
+}
+
+// Parse returns program from source in free format: optional label at beginning of line, statement and
+// operands separated by white space. Lines beginning with "*" or ";" and text after ";" are comments,
+// text after operands is comment too.
+func Parse(Name string, Source io.Reader) (*Program, error) {
+	p := &Program{Name: Name, Labels: make(map[string]int), Storages: make(map[string]int)}
+	scanner := bufio.NewScanner(Source)
+	var labels []token
+	var lines []int
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "*") {
+			continue
+		}
+		fields := tokens(text)
+		if len(fields) == 0 {
+			continue
+		}
+		label := token{}
+		if text[0] != ' ' && text[0] != '\t' {
+			label, fields = fields[0], fields[1:]
+			if len(fields) == 0 {
+				return nil, p.fail(line, label.column, "statement expected after label %q", label.text)
+			}
+		}
+		verb, column := strings.ToUpper(fields[0].text), fields[0].column
+		fields = fields[1:]
+		switch verb {
+		case "SIMULATE", "END":
+			continue
+		case "START":
+			if len(fields) == 0 {
+				return nil, p.fail(line, len(text)+1, "termination count expected")
+			}
+			count, err := strconv.Atoi(strings.Split(fields[0].text, ",")[0])
+			if err != nil || count < 1 {
+				return nil, p.fail(line, fields[0].column, "incorrect termination count %q", fields[0].text)
+			}
+			p.Starts = append(p.Starts, count)
+			continue
+		case "STORAGE":
+			if label.text == "" || len(fields) == 0 {
+				return nil, p.fail(line, fields0(fields, text), "STORAGE needs name in label field and capacity")
+			}
+			capacity, err := strconv.Atoi(fields[0].text)
+			if err != nil || capacity < 1 {
+				return nil, p.fail(line, fields[0].column, "incorrect capacity %q", fields[0].text)
+			}
+			p.Storages[label.text] = capacity
+			continue
+		}
+		shape, ok := blockOperands[verb]
+		if !ok {
+			return nil, p.fail(line, column, "unknown statement %q", verb)
+		}
+		block := &Block{Line: line, Column: column, Label: label.text, Type: verb,
+			Names: make([]string, shape.count), Operands: make([]*SNA, shape.count), Targets: []int{-1, -1, -1}}
+		if verb == "TEST" {
+			if len(fields) == 0 || !relations[strings.ToUpper(fields[0].text)] {
+				return nil, p.fail(line, fields0(fields, text), "relation L, LE, E, NE, G or GE expected")
+			}
+			block.Relation, fields = strings.ToUpper(fields[0].text), fields[1:]
+		}
+		var list []token
+		if len(fields) != 0 {
+			list = operands(fields[0])
+		}
+		if len(list) > shape.count {
+			return nil, p.fail(line, list[shape.count].column, "too many operands of %s", verb)
+		}
+		for i, operand := range list {
+			if operand.text == "" {
+				continue
+			}
+			switch {
+			case contains(shape.names, i):
+				block.Names[i] = operand.text
+			case contains(shape.labels, i):
+				block.Names[i] = operand.text
+				labels = append(labels, operand)
+				lines = append(lines, line)
+			case verb == "TRANSFER" && i == 0 && strings.ToUpper(operand.text) == "BOTH":
+				block.Names[0] = "BOTH"
+			case verb == "UNLINK" && i == 2 && strings.ToUpper(operand.text) == "ALL":
+				block.Names[2] = "ALL"
+			default:
+				sna, err := p.sna(line, operand)
+				if err != nil {
+					return nil, err
+				}
+				block.Operands[i] = sna
+			}
+		}
+		if err := p.check(block, list, fields0(fields, text)); err != nil {
+			return nil, err
+		}
+		if label.text != "" {
+			if _, ok := p.Labels[label.text]; ok {
+				return nil, p.fail(line, label.column, "duplicate label %q", label.text)
+			}
+			p.Labels[label.text] = len(p.Blocks)
+		}
+		p.Blocks = append(p.Blocks, block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i, label := range labels {
+		if _, ok := p.Labels[label.text]; !ok {
+			return nil, p.fail(lines[i], label.column, "unknown label %q", label.text)
+		}
+	}
+	for _, block := range p.Blocks {
+		for i, name := range block.Names {
+			if contains(blockOperands[block.Type].labels, i) && name != "" {
+				block.Targets[i] = p.Labels[name]
+			}
+		}
+		if (block.Type == "ENTER" || block.Type == "LEAVE") && p.Storages[block.Names[0]] == 0 {
+			return nil, p.fail(block.Line, block.Column, "storage %q isn't defined", block.Names[0])
+		}
+		for _, operand := range block.Operands {
+			if operand == nil {
+				continue
+			}
+			if operand.Type == Storage || operand.Type == Remaining {
+				if p.Storages[operand.Name] == 0 {
+					return nil, p.fail(block.Line, block.Column, "storage %q isn't defined", operand.Name)
+				}
+			}
+			if operand.Type == BlockTotal || operand.Type == BlockCount {
+				if _, ok := p.Labels[operand.Name]; !ok {
+					return nil, p.fail(block.Line, block.Column, "unknown label %q", operand.Name)
+				}
+			}
+		}
+	}
+	if len(p.Blocks) == 0 {
+		return nil, &Error{Name, 1, 1, "no blocks in model"}
+	}
+	if len(p.Starts) == 0 {
+		return nil, &Error{Name, 1, 1, "no START statement in model"}
+	}
+	return p, nil
+}
+
+// fields0 returns column of first field or end of line if there is no field.
+func fields0(fields []token, text string) int {
+	if len(fields) == 0 {
+		return len(text) + 1
+	}
+	return fields[0].column
+}
+
+func contains(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// check checks required operands of block.
+func (p *Program) check(block *Block, list []token, column int) error {
+	operand := func(i int) (*SNA, int) {
+		if i < len(list) {
+			return block.Operands[i], list[i].column
+		}
+		return nil, column
+	}
+	switch block.Type {
+	case "GENERATE":
+		if a, c := operand(0); a == nil && block.Operands[3] == nil {
+			return p.fail(block.Line, c, "GENERATE needs mean interval or limit count")
+		}
+		for i := 0; i < 5; i++ {
+			if a, c := operand(i); a != nil && (a.Type != Constant || a.Value < 0) {
+				return p.fail(block.Line, c, "operand of GENERATE must be non-negative constant")
+			}
+		}
+	case "ADVANCE":
+		if b, c := operand(1); b != nil && (b.Type != Constant || b.Value < 0) {
+			return p.fail(block.Line, c, "spread of ADVANCE must be non-negative constant")
+		}
+	case "SEIZE", "RELEASE", "QUEUE", "DEPART", "ENTER", "LEAVE", "LINK", "UNLINK":
+		if block.Names[0] == "" {
+			_, c := operand(0)
+			return p.fail(block.Line, c, "%s needs name", block.Type)
+		}
+		if block.Type == "LINK" && block.Names[1] != "" {
+			block.Names[1] = strings.ToUpper(block.Names[1])
+			if _, ok := chainOrders[block.Names[1]]; !ok {
+				_, c := operand(1)
+				return p.fail(block.Line, c, "order of LINK must be FIFO, LIFO or PR")
+			}
+		}
+		if block.Type == "UNLINK" && block.Names[1] == "" {
+			_, c := operand(1)
+			return p.fail(block.Line, c, "UNLINK needs label of block for unlinked transactions")
+		}
+	case "SPLIT", "ASSEMBLE":
+		if a, c := operand(0); a == nil {
+			return p.fail(block.Line, c, "%s needs count", block.Type)
+		}
+		if block.Type == "SPLIT" && block.Names[1] == "" {
+			_, c := operand(1)
+			return p.fail(block.Line, c, "SPLIT needs label of block for children")
+		}
+	case "TEST":
+		for i := 0; i < 2; i++ {
+			if a, c := operand(i); a == nil {
+				return p.fail(block.Line, c, "TEST needs two operands")
+			}
+		}
+	case "TRANSFER":
+		a, c := operand(0)
+		switch {
+		case block.Names[0] == "BOTH":
+			if block.Names[1] == "" || block.Names[2] == "" {
+				return p.fail(block.Line, c, "TRANSFER BOTH needs two labels")
+			}
+		case a == nil:
+			if block.Names[1] == "" {
+				_, c := operand(1)
+				return p.fail(block.Line, c, "TRANSFER needs label")
+			}
+		case a.Type != Constant || a.Value < 0 || a.Value > 1:
+			return p.fail(block.Line, c, "selection factor of TRANSFER must be fraction")
+		case block.Names[2] == "":
+			_, c := operand(2)
+			return p.fail(block.Line, c, "statistical TRANSFER needs label of selected block")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package gpss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	source := `* Barber shop
+        SIMULATE
+SHOP    STORAGE   2
+        GENERATE  18,6          ; customers
+        QUEUE     WAIT
+        ENTER     SHOP
+        DEPART    WAIT
+        TEST GE   M1,10,DONE
+        ADVANCE   16,4
+DONE    LEAVE     SHOP
+        TRANSFER  .5,,OUT
+OUT     TERMINATE 1
+        START     100
+        END
+`
+	p, err := Parse("shop", strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Blocks) != 9 {
+		t.Fatalf("Expected 9 blocks, got %d", len(p.Blocks))
+	}
+	if p.Storages["SHOP"] != 2 || len(p.Starts) != 1 || p.Starts[0] != 100 {
+		t.Errorf("Expected storage SHOP of 2 and START 100, got %v and %v", p.Storages, p.Starts)
+	}
+	test := p.Blocks[4]
+	if test.Type != "TEST" || test.Relation != "GE" || test.Operands[0].Type != Transit || test.Operands[1].Value != 10 || test.Targets[2] != 6 {
+		t.Errorf("Incorrect TEST block: %+v", test)
+	}
+	if transfer := p.Blocks[7]; transfer.Operands[0].Value != 0.5 || transfer.Targets[1] != -1 || transfer.Targets[2] != 8 {
+		t.Errorf("Incorrect TRANSFER block: %+v", transfer)
+	}
+	if block := p.Blocks[0]; block.Line != 4 || block.Column != 9 {
+		t.Errorf("Expected GENERATE at 4:9, got %d:%d", block.Line, block.Column)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		source, message string
+	}{
+		{" GENERATE 10\n SEIZ X\n START 1", "test:2:2: unknown statement \"SEIZ\""},
+		{" GENERATE 10\n TRANSFER ,NOWHERE\n START 1", "test:2:12: unknown label \"NOWHERE\""},
+		{" GENERATE 10\n TEST X 1,2\n START 1", "test:2:7: relation L, LE, E, NE, G or GE expected"},
+		{" GENERATE 10\n ADVANCE 1,Q$A\n START 1", "test:2:12: spread of ADVANCE must be non-negative constant"},
+		{" GENERATE 10\n ADVANCE 1,2,3\n START 1", "test:2:14: too many operands of ADVANCE"},
+		{" GENERATE 10\n ENTER ROOM\n START 1", "test:2:2: storage \"ROOM\" isn't defined"},
+		{" GENERATE 10,X1\n START 1", "test:1:14: unknown operand \"X1\""},
+		{"A GENERATE 10\nA TERMINATE\n START 1", "test:2:1: duplicate label \"A\""},
+		{" GENERATE 10\n START 0", "test:2:8: incorrect termination count \"0\""},
+		{" GENERATE 10\n TERMINATE 1", "test:1:1: no START statement in model"},
+	}
+	for _, c := range cases {
+		_, err := Parse("test", strings.NewReader(c.source))
+		if err == nil || err.Error() != c.message {
+			t.Errorf("Expected error %q, got %v", c.message, err)
+		}
+	}
+}
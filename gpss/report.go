@@ -0,0 +1,89 @@
+package gpss
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ratio returns quotient or zero if divisor is zero.
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// WriteReport writes standard report of model: counts of blocks and statistics of facilities, queues, storages,
+// user chains and families of split transactions.
+func WriteReport(Writer io.Writer, m *Model) error {
+	now := m.S.GetSimTime()
+	lines := []string{fmt.Sprintf("%s standard report, clock %.3f\n", m.P.Name, now)}
+	lines = append(lines, fmt.Sprintf("\n%-12s %5s %-10s %12s %14s\n", "LABEL", "LOC", "BLOCK TYPE", "ENTRY COUNT", "CURRENT COUNT"))
+	for i, block := range m.P.Blocks {
+		lines = append(lines, fmt.Sprintf("%-12s %5d %-10s %12d %14d\n", block.Label, i+1, block.Type, m.Total[i], m.Current[i]))
+	}
+	if len(m.Facilities) != 0 {
+		lines = append(lines, fmt.Sprintf("\n%-12s %8s %8s %10s %6s\n", "FACILITY", "ENTRIES", "UTIL.", "AVE. TIME", "OWNER"))
+		for _, f := range m.Facilities {
+			_, busy, err := m.S.GetStatistic(f.point)
+			if err != nil {
+				return err
+			}
+			if f.owner != 0 {
+				busy += now - f.seizedAt
+			}
+			lines = append(lines, fmt.Sprintf("%-12s %8d %8.3f %10.3f %6d\n", f.name, f.entries, ratio(busy, now),
+				ratio(busy, float64(f.entries)), f.owner))
+		}
+	}
+	if queues := m.S.GetQueues(); len(queues) != 0 {
+		lines = append(lines, fmt.Sprintf("\n%-12s %6s %6s %8s %10s %10s %10s %10s\n", "QUEUE", "MAX", "CONT.", "ENTRIES",
+			"ENTRIES(0)", "AVE.CONT.", "AVE.TIME", "AVE.(-0)"))
+		for _, name := range queues {
+			q := m.S.GetQueueStatistic(name)
+			lines = append(lines, fmt.Sprintf("%-12s %6d %6d %8d %10d %10.3f %10.3f %10.3f\n", q.Name, q.Max, q.Content, q.Entries,
+				q.ZeroEntries, q.AverageContent, q.AverageTime, q.AverageNonZero))
+		}
+	}
+	if len(m.Storages) != 0 {
+		lines = append(lines, fmt.Sprintf("\n%-12s %6s %6s %6s %8s %10s %8s\n", "STORAGE", "CAP.", "REM.", "MAX", "ENTRIES", "AVE.CONT.", "UTIL."))
+		for _, s := range m.Storages {
+			lines = append(lines, fmt.Sprintf("%-12s %6d %6d %6d %8d %10.3f %8.3f\n", s.name, s.capacity, s.capacity-s.content,
+				s.max, s.entries, ratio(s.integral, now), ratio(s.integral, now*float64(s.capacity))))
+		}
+	}
+	if len(m.links) != 0 {
+		names := make([]string, 0, len(m.links))
+		for name := range m.links {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines = append(lines, fmt.Sprintf("\n%-12s %8s %8s\n", "USER CHAIN", "ENTRIES", "CONT."))
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("%-12s %8d %8d\n", name, m.links[name], len(m.S.GetChain(name))))
+		}
+	}
+	if families := m.S.GetFamilies(); len(families) != 0 {
+		completed, lifetime, members := 0, 0.0, 0
+		for _, id := range families {
+			f, err := m.S.GetFamilyStatistic(id)
+			if err != nil {
+				return err
+			}
+			members += f.Members
+			if f.Alive == 0 {
+				completed++
+				lifetime += f.Lifetime
+			}
+		}
+		lines = append(lines, fmt.Sprintf("\n%-12s %8s %10s %12s\n", "FAMILIES", "MEMBERS", "COMPLETED", "AVE.LIFETIME"))
+		lines = append(lines, fmt.Sprintf("%-12d %8d %10d %12.3f\n", len(families), members, completed, ratio(lifetime, float64(completed))))
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(Writer, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
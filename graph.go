@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"simulation-modeling/sim"
+)
+
+// Movement of transaction from current waypoint to next one, new next waypoint is set by it.
+type Movement struct {
+	Id             int
+	Time           float64
+	From, To, Next int
+}
+
+// Journal of movements of transactions in simulation run.
+type Journal struct {
+	Movements []Movement
+}
+
+// Occupation of point by transaction from start to end time, previous is point released when it was seized.
+type Occupancy struct {
+	Id, Point, Previous int
+	Start, End          float64
+}
+
+// Reset clears journal before simulation run.
+func (J *Journal) Reset() {
+	J.Movements = nil
+}
+
+// Record adds movement of transaction to next waypoint at current time.
+func (J *Journal) Record(S *sim.Sim, Tr *sim.Transaction, NextPoint int) {
+	points := sim.GetPoints(*Tr)
+	J.Movements = append(J.Movements, Movement{sim.GetId(*Tr), S.GetSimTime(), points.Current, points.Next, NextPoint})
+}
+
+// Occupancies returns occupation intervals of points, points still occupied are occupied until end time.
+// Train leaves line from terminal station at the moment it arrives to it.
+func (J *Journal) Occupancies(End float64) []Occupancy {
+	var result []Occupancy
+	open := make(map[int]int)
+	for _, movement := range J.Movements {
+		if i, ok := open[movement.Id]; ok {
+			result[i].End = movement.Time
+			delete(open, movement.Id)
+		}
+		result = append(result, Occupancy{movement.Id, movement.To, movement.From, movement.Time, movement.Time})
+		if movement.Next != Point0 {
+			open[movement.Id] = len(result) - 1
+		}
+	}
+	for _, i := range open {
+		result[i].End = End
+	}
+	return result
+}
+
+// span returns position of ends of point along line in stations, station i is at position i.
+// Block of section occupies its part of section. It returns false for points off line.
+func (M *Model) span(Point int) (float64, float64, bool) {
+	if i, ok := M.stationIndex(Point); ok {
+		return float64(i), float64(i), true
+	}
+	j, ok := M.sectionIndex(Point)
+	if !ok {
+		return 0, 0, false
+	}
+	points := M.Sections[j].Points
+	blocks := len(points)
+	if blocks > 1 {
+		blocks /= 2
+	}
+	for k, point := range points {
+		if point == Point {
+			block := float64(k % blocks)
+			return float64(j) + block/float64(blocks), float64(j) + (block+1)/float64(blocks), true
+		}
+	}
+	return 0, 0, false
+}
+
+// PointLabel returns name of point of station track or section.
+func PointLabel(M *Model, Point int) string {
+	if i, ok := M.stationIndex(Point); ok {
+		station := M.Stations[i]
+		if len(station.Tracks) == 1 {
+			return StationLabel(station)
+		}
+		for k, track := range station.Tracks {
+			if track == Point {
+				return fmt.Sprintf("%s track %d", StationLabel(station), k+1)
+			}
+		}
+	}
+	if j, ok := M.sectionIndex(Point); ok {
+		section := M.Sections[j]
+		if len(section.Points) == 1 {
+			return "section " + section.Name
+		}
+		for k, point := range section.Points {
+			if point == Point {
+				return fmt.Sprintf("section %s %d/%d", section.Name, M.directions[Point]+1, k%(len(section.Points)/2)+1)
+			}
+		}
+	}
+	return fmt.Sprintf("point %d", Point)
+}
+
+// Layout of chart in SVG: time window on X axis and rows on Y axis.
+type chart struct {
+	From, To      float64
+	Width, Height float64
+	Left, Top     float64
+}
+
+const chartRight, chartBottom = 20.0, 40.0
+
+func (C chart) x(Time float64) float64 {
+	return C.Left + (Time-C.From)/(C.To-C.From)*(C.Width-C.Left-chartRight)
+}
+
+func (C chart) begin(Writer *bufio.Writer, Title string) {
+	fmt.Fprintf(Writer, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" font-family=\"sans-serif\" font-size=\"12\">\n", C.Width, C.Height)
+	fmt.Fprintf(Writer, "<title>%s</title>\n<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n", html.EscapeString(Title))
+	fmt.Fprintf(Writer, "<clipPath id=\"plot\"><rect x=\"%.1f\" y=\"0\" width=\"%.1f\" height=\"%.1f\"/></clipPath>\n",
+		C.Left, C.Width-C.Left-chartRight, C.Height)
+	// Time axis with ticks by round number of minutes.
+	step := 10.0
+	for (C.To-C.From)/step > 20 {
+		step *= 2
+	}
+	for t := float64(int(C.From/step)) * step; t <= C.To; t += step {
+		if t < C.From {
+			continue
+		}
+		fmt.Fprintf(Writer, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"#ddd\"/>\n", C.x(t), C.Top, C.x(t), C.Height-chartBottom)
+		fmt.Fprintf(Writer, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\">%02d:%02d</text>\n", C.x(t), C.Height-chartBottom+16, int(t)/60, int(t)%60)
+	}
+}
+
+func (C chart) end(Writer *bufio.Writer) {
+	fmt.Fprintln(Writer, "</svg>")
+}
+
+// color returns color of transaction.
+func color(Id int) string {
+	return fmt.Sprintf("hsl(%d,70%%,40%%)", Id*47%360)
+}
+
+// WriteTrainGraph writes time-distance diagram of trains in time window in minutes as SVG.
+// Stations are on Y axis in order of line, dwelling train is horizontal line, running train is sloped line.
+func WriteTrainGraph(Output io.Writer, M *Model, J *Journal, From, To float64) error {
+	writer := bufio.NewWriter(Output)
+	rowHeight := 60.0
+	C := chart{From, To, 1200, 40 + rowHeight*float64(len(M.Stations)-1) + chartBottom, 120, 40}
+	y := func(position float64) float64 { return C.Top + position*rowHeight }
+	C.begin(writer, M.Title+" train graph")
+	for i, station := range M.Stations {
+		fmt.Fprintf(writer, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"#999\"/>\n", C.Left, y(float64(i)), C.x(To), y(float64(i)))
+		fmt.Fprintf(writer, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"end\">%s</text>\n", C.Left-8, y(float64(i))+4, html.EscapeString(StationLabel(station)))
+	}
+	fmt.Fprintln(writer, "<g clip-path=\"url(#plot)\" stroke-width=\"1.5\">")
+	for _, occupancy := range J.Occupancies(To) {
+		if occupancy.End < From || occupancy.Start > To {
+			continue
+		}
+		low, high, ok := M.span(occupancy.Point)
+		if !ok || occupancy.Start == occupancy.End && low == high {
+			continue
+		}
+		// Train runs back to first station if it came from station at far end of section or by its direction.
+		if _, in := M.sectionIndex(occupancy.Point); in {
+			backward := M.directions[occupancy.Point] == 1
+			if _, directed := M.directions[occupancy.Point]; !directed {
+				previous, _, _ := M.span(occupancy.Previous)
+				backward = previous > low
+			}
+			if backward {
+				low, high = high, low
+			}
+		}
+		fmt.Fprintf(writer, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\"><title>train %d</title></line>\n",
+			C.x(occupancy.Start), y(low), C.x(occupancy.End), y(high), color(occupancy.Id), occupancy.Id)
+	}
+	fmt.Fprintln(writer, "</g>")
+	C.end(writer)
+	return writer.Flush()
+}
+
+// WriteGantt writes chart of occupation of points in time window in minutes as SVG, one row for each point.
+func WriteGantt(Output io.Writer, M *Model, J *Journal, From, To float64) error {
+	writer := bufio.NewWriter(Output)
+	var rows []int
+	for i, station := range M.Stations {
+		rows = append(rows, station.Tracks...)
+		if i < len(M.Sections) {
+			rows = append(rows, M.Sections[i].Points...)
+		}
+	}
+	row := make(map[int]int, len(rows))
+	for i, point := range rows {
+		row[point] = i
+	}
+	rowHeight := 20.0
+	C := chart{From, To, 1200, 40 + rowHeight*float64(len(rows)) + chartBottom, 160, 40}
+	C.begin(writer, M.Title+" occupation of points")
+	for i, point := range rows {
+		fmt.Fprintf(writer, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"end\">%s</text>\n", C.Left-8, C.Top+float64(i)*rowHeight+14, html.EscapeString(PointLabel(M, point)))
+	}
+	fmt.Fprintln(writer, "<g clip-path=\"url(#plot)\">")
+	for _, occupancy := range J.Occupancies(To) {
+		i, ok := row[occupancy.Point]
+		if !ok || occupancy.End < From || occupancy.Start > To {
+			continue
+		}
+		width := C.x(occupancy.End) - C.x(occupancy.Start)
+		if width < 1 {
+			width = 1
+		}
+		fmt.Fprintf(writer, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\"><title>train %d</title></rect>\n",
+			C.x(occupancy.Start), C.Top+float64(i)*rowHeight+2, width, rowHeight-4, color(occupancy.Id), occupancy.Id)
+	}
+	fmt.Fprintln(writer, "</g>")
+	C.end(writer)
+	return writer.Flush()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOccupancies(t *testing.T) {
+	J := Journal{[]Movement{
+		{1, 0, Point0, PointA, PointAC},
+		{2, 3, Point0, PointB, PointBC},
+		{1, 5, PointA, PointAC, PointCm},
+		{1, 20, PointAC, PointCm, PointBC},
+		{1, 30, PointCm, PointBC, PointB},
+		{1, 50, PointBC, PointB, Point0},
+	}}
+	// Train leaves line at terminal at once, point still occupied at end is closed by end time.
+	expected := []Occupancy{
+		{1, PointA, Point0, 0, 5},
+		{2, PointB, Point0, 3, 60},
+		{1, PointAC, PointA, 5, 20},
+		{1, PointCm, PointAC, 20, 30},
+		{1, PointBC, PointCm, 30, 50},
+		{1, PointB, PointBC, 50, 50},
+	}
+	if occupancies := J.Occupancies(60); !reflect.DeepEqual(occupancies, expected) {
+		t.Errorf("Expected occupancies %v, got %v", expected, occupancies)
+	}
+	if occupancies := (&Journal{}).Occupancies(60); len(occupancies) != 0 {
+		t.Errorf("Expected no occupancies of empty journal, got %v", occupancies)
+	}
+}
+
+func TestSpan(t *testing.T) {
+	stations := []StationConfig{{Name: "A", Tracks: 1}, {Name: "C", Tracks: 2}, {Name: "B", Tracks: 1}}
+	sections := []SectionConfig{{Transit: testTransit, Double: true, Blocks: 2}, {Transit: testTransit}}
+	M := testModel(t, &LineConfig{stations, sections}, 24)
+	tests := []struct {
+		point     int
+		low, high float64
+		ok        bool
+	}{
+		{1, 0, 0, true},
+		{3, 1, 1, true},
+		// Blocks of each direction split double track section, train leaving loop enters block next to it.
+		{5, 0, 0.5, true},
+		{6, 0.5, 1, true},
+		{8, 0.5, 1, true},
+		{7, 0, 0.5, true},
+		{9, 1, 2, true},
+		{Point0, 0, 0, false},
+		{M.Clock, 0, 0, false},
+	}
+
+	for i, test := range tests {
+		if low, high, ok := M.span(test.point); low != test.low || high != test.high || ok != test.ok {
+			t.Errorf("Test %d: expected span %.2f-%.2f (%t) of point %d, got %.2f-%.2f (%t)",
+				i, test.low, test.high, test.ok, test.point, low, high, ok)
+		}
+	}
+}
+
+func TestWriteCharts(t *testing.T) {
+	M := testModel(t, nil, 4)
+	S := simulate(M, FIFODispatcher{}, 1)
+	end := S.GetSimTime()
+	// Names from configuration are escaped.
+	M.Title, M.Stations[1].Name = "Line <A & B>", "C&D"
+
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		"train graph": func(B *bytes.Buffer) error { return WriteTrainGraph(B, M, M.Journal, 0, end) },
+		"gantt":       func(B *bytes.Buffer) error { return WriteGantt(B, M, M.Journal, 0, end) },
+	} {
+		var buffer bytes.Buffer
+		if err := write(&buffer); err != nil {
+			t.Fatal(err)
+		}
+		svg := buffer.String()
+		decoder := xml.NewDecoder(&buffer)
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Expected valid XML of %s, got %v", name, err)
+			}
+		}
+		if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") ||
+			!strings.Contains(svg, "<title>train 2</title>") || !strings.Contains(svg, ">station B</text>") {
+			t.Errorf("Expected %s of trains with stations, got %q", name, svg)
+		}
+	}
+}
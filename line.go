@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"simulation-modeling/sim"
+)
+
+// Station of line in configuration.
+// Terminal stations have one track, passing loops have two or more tracks.
+type StationConfig struct {
+	Name     string              `json:"name"`
+	Tracks   int                 `json:"tracks"`
+	Headway  *DistributionConfig `json:"headway,omitempty"`
+	Priority int                 `json:"priority,omitempty"`
+}
+
+// Section between neighbouring stations in configuration.
+// Section is single-track or double-track with one track for each direction. Section can be split into
+// fixed signal blocks, then following trains of the same direction occupy separate blocks.
+// Next train of the same direction enters section not earlier than minimum headway after previous one.
+// Transit time is computed by motion of train over track profile if it's specified.
+type SectionConfig struct {
+	Transit DistributionConfig `json:"transit"`
+	Profile *ProfileConfig     `json:"profile,omitempty"`
+	Double  bool               `json:"double,omitempty"`
+	Blocks  int                `json:"blocks,omitempty"`
+	Headway float64            `json:"headway,omitempty"`
+}
+
+// Linear single-track line with ordered stations in configuration.
+type LineConfig struct {
+	Stations []StationConfig `json:"stations"`
+	Sections []SectionConfig `json:"sections"`
+}
+
+// BuildLine returns model of line with passing loops.
+// First and last stations are terminals, trains arrive at them by their headway timing or by common station timing.
+// Tracks of passing loops are split between directions: even tracks are used by trains from first station,
+// odd tracks by trains from last station. Train enters single-track section only if no opposing train is in it,
+// it leaves section for free track of its direction on next station, so opposing trains can't block each other
+// and following trains don't run into train waiting on passing loop.
+func BuildLine(Line LineConfig, Train *TrainConfig, TimeTable map[int]sim.Distribution) (*Model, error) {
+	stations, sections := Line.Stations, Line.Sections
+	if len(stations) < 2 {
+		return nil, errors.New(fmt.Sprintf("line must have at least 2 stations, got %d", len(stations)))
+	}
+	if len(sections) != len(stations)-1 {
+		return nil, errors.New(fmt.Sprintf("line with %d stations must have %d sections, got %d", len(stations), len(stations)-1, len(sections)))
+	}
+
+	model := &Model{Title: "Line", TimeTable: make(map[int]sim.Distribution),
+		CheckTable: make(map[sim.Points][]int), RoadMap: make(map[Checks][]Action), directions: make(map[int]int)}
+	for timing, distribution := range TimeTable {
+		model.TimeTable[timing] = distribution
+	}
+	point, timing := Point0+1, Limits
+	for i, station := range stations {
+		terminal := i == 0 || i == len(stations)-1
+		switch {
+		case terminal && station.Tracks > 1:
+			return nil, errors.New(fmt.Sprintf("terminal station %s must have 1 track, got %d", station.Name, station.Tracks))
+		case !terminal && station.Tracks < 2:
+			return nil, errors.New(fmt.Sprintf("passing loop %s must have at least 2 tracks, got %d", station.Name, station.Tracks))
+		case !terminal && station.Headway != nil:
+			return nil, errors.New(fmt.Sprintf("passing loop %s can't have headway", station.Name))
+		}
+		ms := ModelStation{Name: station.Name, Terminal: terminal, Headway: Station, Priority: station.Priority}
+		for track := 0; track < station.Tracks || track < 1; track++ {
+			ms.Tracks = append(ms.Tracks, point)
+			point++
+		}
+		if station.Headway != nil {
+			distribution, err := sim.NewDistribution(station.Headway.Distribution, station.Headway.Parameters)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("headway of station %s: %s", station.Name, err))
+			}
+			model.TimeTable[timing] = distribution
+			ms.Headway = timing
+			timing++
+		}
+		model.Stations = append(model.Stations, ms)
+	}
+	lanes := make([][2]lane, len(sections))
+	for i, section := range sections {
+		name := stations[i].Name + stations[i+1].Name
+		if section.Blocks < 0 || section.Headway < 0 {
+			return nil, errors.New(fmt.Sprintf("incorrect blocks or headway of section %s", name))
+		}
+		blocks := section.Blocks
+		if blocks < 1 {
+			blocks = 1
+		}
+		ms := ModelSection{Name: name, Blocks: blocks}
+		if section.Double {
+			ms.Blocks *= 2
+		}
+		// Transit timings by direction, they differ only if computed by profile.
+		var timings [2]int
+		for direction := range timings {
+			if direction == 1 && section.Profile == nil {
+				timings[1] = timings[0]
+				break
+			}
+			var distribution sim.Distribution
+			var err error
+			switch {
+			case section.Profile == nil:
+				distribution, err = sim.NewDistribution(section.Transit.Distribution, section.Transit.Parameters)
+			case Train == nil:
+				err = errors.New("train isn't configured for profile")
+			default:
+				terminal := [2]bool{model.Stations[i].Terminal, model.Stations[i+1].Terminal}
+				if ms.Running[direction], err = Train.Run(*section.Profile, direction, terminal); err == nil {
+					distribution, err = section.Profile.Timing(ms.Running[direction].Time)
+				}
+			}
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("transit of section %s: %s", name, err))
+			}
+			if blocks > 1 {
+				distribution = sim.Scaled{distribution, 1 / float64(blocks)}
+			}
+			model.TimeTable[timing] = distribution
+			timings[direction] = timing
+			timing++
+		}
+		// Points of blocks by direction, single-track section without blocks has one point for both directions.
+		var points [2][]int
+		for direction := range points {
+			if direction == 1 && !section.Double && blocks == 1 {
+				points[1] = points[0]
+				break
+			}
+			for block := 0; block < blocks; block++ {
+				points[direction] = append(points[direction], point)
+				model.directions[point] = direction
+				ms.Points = append(ms.Points, point)
+				point++
+			}
+		}
+		if !section.Double && blocks == 1 {
+			delete(model.directions, points[0][0])
+		}
+		for direction := range lanes[i] {
+			l := lane{timing: timings[direction], signal: Point0}
+			l.path = append(l.path, points[direction]...)
+			if direction == 1 {
+				for a, b := 0, len(l.path)-1; a < b; a, b = a+1, b-1 {
+					l.path[a], l.path[b] = l.path[b], l.path[a]
+				}
+			}
+			l.conflicts = []int{l.path[0]}
+			if !section.Double && blocks > 1 {
+				l.conflicts = append(l.conflicts, points[1-direction]...)
+			}
+			lanes[i][direction] = l
+		}
+		if section.Headway > 0 {
+			model.TimeTable[timing] = sim.Pair{section.Headway, section.Headway}
+			for direction := range lanes[i] {
+				lanes[i][direction].signal, lanes[i][direction].headway = point, timing
+				lanes[i][direction].conflicts = append(lanes[i][direction].conflicts, point)
+				model.RoadMap[Checks{Point0, point, true}] = []Action{Action{Release, []int{point}}}
+				point++
+			}
+			timing++
+		}
+		model.Sections = append(model.Sections, ms)
+	}
+	model.Clock = point
+	model.Points = point + 1
+	model.RoadMap[Checks{Point0, model.Clock, true}] = []Action{Action{Terminate, []int{}}}
+
+	model.direction(0, lanes)
+	model.direction(1, lanes)
+	return model, nil
+}
+
+// Points of section used by trains of one direction.
+// Train passes points of path in order, it enters section if conflicting points are free.
+// Signal point is held for minimum headway after train enters section.
+type lane struct {
+	path, conflicts []int
+	timing          int
+	signal, headway int
+}
+
+// direction adds checks and transitions for trains from first (0) or last (1) station.
+func (M *Model) direction(Direction int, Lanes [][2]lane) {
+	n := len(M.Stations)
+	station := func(i int) ModelStation { return M.Stations[i] }
+	section := func(i int) lane { return Lanes[i][Direction] }
+	if Direction == 1 {
+		station = func(i int) ModelStation { return M.Stations[n-1-i] }
+		section = func(i int) lane { return Lanes[n-2-i][Direction] }
+	}
+	// Tracks of station available for trains of direction.
+	tracks := func(i int) []int {
+		if station(i).Terminal {
+			return station(i).Tracks
+		}
+		result := make([]int, 0, len(station(i).Tracks))
+		for track := Direction; track < len(station(i).Tracks); track += 2 {
+			result = append(result, station(i).Tracks[track])
+		}
+		return result
+	}
+
+	origin := station(0).Tracks[0]
+	M.CheckTable[sim.Points{Point0, origin}] = section(0).conflicts
+	M.RoadMap[Checks{Point0, origin, false}] = []Action{Action{Wait, []int{}}, Action{Generate, []int{station(0).Headway, origin, station(0).Priority}}}
+	M.RoadMap[Checks{Point0, origin, true}] = []Action{Action{Use, []int{0, section(0).path[0]}}, Action{Generate, []int{station(0).Headway, origin, station(0).Priority}}}
+
+	for i := 0; i < n-1; i++ {
+		l := section(i)
+		// Candidates of next waypoint after k-th point of path.
+		next := func(k int) []int {
+			if k+1 < len(l.path) {
+				return []int{l.path[k+1]}
+			}
+			return tracks(i + 1)
+		}
+		// Train enters last point of path only if the single track of its direction on next passing loop is free,
+		// otherwise it could run into train waiting on it. Free track among several is chosen by Use.
+		checks := func(k int, points []int) []int {
+			if k == len(l.path)-1 && len(tracks(i+1)) == 1 && !station(i+1).Terminal {
+				return append(append([]int(nil), points...), tracks(i + 1)[0])
+			}
+			return points
+		}
+		for _, current := range tracks(i) {
+			M.CheckTable[sim.Points{current, l.path[0]}] = checks(0, l.conflicts)
+			M.RoadMap[Checks{current, l.path[0], false}] = []Action{Action{Wait, []int{}}}
+			actions := []Action{Action{Use, append([]int{l.timing}, next(0)...)}}
+			if l.signal != Point0 {
+				actions = append(actions, Action{Occupy, []int{l.headway, l.signal}})
+			}
+			M.RoadMap[Checks{current, l.path[0], true}] = actions
+		}
+		for k := 1; k < len(l.path); k++ {
+			M.CheckTable[sim.Points{l.path[k-1], l.path[k]}] = checks(k, []int{l.path[k]})
+			M.RoadMap[Checks{l.path[k-1], l.path[k], false}] = []Action{Action{Wait, []int{}}}
+			M.RoadMap[Checks{l.path[k-1], l.path[k], true}] = []Action{Action{Use, append([]int{l.timing}, next(k)...)}}
+		}
+		last := l.path[len(l.path)-1]
+		for _, track := range tracks(i + 1) {
+			if i+1 == n-1 {
+				M.RoadMap[Checks{last, track, true}] = []Action{Action{Use, []int{0, Point0}}}
+			} else {
+				M.RoadMap[Checks{last, track, true}] = []Action{Action{Use, []int{0, section(i + 1).path[0]}}}
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"reflect"
+	"simulation-modeling/sim"
+	"testing"
+)
+
+var testTransit = DistributionConfig{"uniform", []float64{12, 18}}
+
+func TestBuildLine(t *testing.T) {
+	stations := []StationConfig{{Name: "A", Tracks: 1}, {Name: "C", Tracks: 2}, {Name: "B", Tracks: 1}}
+	tests := []struct {
+		sections   []SectionConfig
+		points     int
+		tracks     [][]int
+		blocks     [][]int
+		counts     []int
+		directions map[int]int
+		checks     map[sim.Points][]int
+	}{
+		// Following train enters section only if single track of its direction on loop is free.
+		{[]SectionConfig{{Transit: testTransit}, {Transit: testTransit}},
+			8, [][]int{{1}, {2, 3}, {4}}, [][]int{{5}, {6}}, []int{1, 1}, map[int]int{},
+			map[sim.Points][]int{{1, 5}: {5, 2}, {2, 6}: {6}, {4, 6}: {6, 3}, {3, 5}: {5}}},
+		// Loop track is checked before train enters last block of section.
+		{[]SectionConfig{{Transit: testTransit, Double: true, Blocks: 2}, {Transit: testTransit}},
+			11, [][]int{{1}, {2, 3}, {4}}, [][]int{{5, 6, 7, 8}, {9}}, []int{4, 1}, map[int]int{5: 0, 6: 0, 7: 1, 8: 1},
+			map[sim.Points][]int{{1, 5}: {5}, {5, 6}: {6, 2}, {4, 9}: {9, 3}, {3, 8}: {8}, {8, 7}: {7}}},
+		// Signal points of both directions follow blocks of section.
+		{[]SectionConfig{{Transit: testTransit, Blocks: 2, Headway: 5}, {Transit: testTransit}},
+			13, [][]int{{1}, {2, 3}, {4}}, [][]int{{5, 6, 7, 8}, {11}}, []int{2, 1}, map[int]int{5: 0, 6: 0, 7: 1, 8: 1},
+			map[sim.Points][]int{}},
+	}
+
+	for i, test := range tests {
+		M := testModel(t, &LineConfig{stations, test.sections}, 24)
+		if M.Points != test.points || M.Clock != test.points-1 {
+			t.Errorf("Test %d: expected %d points, got %d with clock %d", i, test.points, M.Points, M.Clock)
+		}
+		for j, station := range M.Stations {
+			if !reflect.DeepEqual(station.Tracks, test.tracks[j]) || station.Terminal != (j != 1) {
+				t.Errorf("Test %d: expected tracks %v of station %s, got %+v", i, test.tracks[j], station.Name, station)
+			}
+		}
+		for j, section := range M.Sections {
+			if !reflect.DeepEqual(section.Points, test.blocks[j]) || section.Blocks != test.counts[j] {
+				t.Errorf("Test %d: expected points %v and %d blocks of section %s, got %+v", i, test.blocks[j], test.counts[j], section.Name, section)
+			}
+		}
+		if !reflect.DeepEqual(M.directions, test.directions) {
+			t.Errorf("Test %d: expected directions %v, got %v", i, test.directions, M.directions)
+		}
+		for points, checks := range test.checks {
+			if !reflect.DeepEqual(M.CheckTable[points], checks) {
+				t.Errorf("Test %d: expected checks %v of movement %v, got %v", i, checks, points, M.CheckTable[points])
+			}
+		}
+	}
+}
+
+func TestSignal(t *testing.T) {
+	line := LineConfig{
+		[]StationConfig{{Name: "A", Tracks: 1}, {Name: "B", Tracks: 1}},
+		[]SectionConfig{{Transit: testTransit, Double: true, Blocks: 2, Headway: 10}},
+	}
+	M := testModel(t, &line, 24)
+	// Signal is held for headway only after train enters section.
+	blocks := M.Sections[0].Points
+	for _, entry := range []sim.Points{{M.Stations[0].Tracks[0], blocks[0]}, {M.Stations[1].Tracks[0], blocks[len(blocks)-1]}} {
+		actions := M.RoadMap[Checks{entry.Current, entry.Next, true}]
+		if len(actions) != 2 || actions[0].Type != Use || actions[1].Type != Occupy {
+			t.Errorf("Expected use of block %d before occupation of signal, got %v", entry.Next, actions)
+			continue
+		}
+		if headway := M.TimeTable[actions[1].Arguments[0]].Mean(); headway != 10 {
+			t.Errorf("Expected headway 10 of signal, got %.2f", headway)
+		}
+	}
+}
+
+func TestBuildLineErrors(t *testing.T) {
+	terminal, loop := StationConfig{Name: "A", Tracks: 1}, StationConfig{Name: "C", Tracks: 2}
+	tests := []LineConfig{
+		{[]StationConfig{terminal}, nil},
+		{[]StationConfig{terminal, terminal}, []SectionConfig{{Transit: testTransit}, {Transit: testTransit}}},
+		{[]StationConfig{{Name: "A", Tracks: 2}, terminal}, []SectionConfig{{Transit: testTransit}}},
+		{[]StationConfig{terminal, {Name: "C", Tracks: 1}, terminal}, []SectionConfig{{Transit: testTransit}, {Transit: testTransit}}},
+		{[]StationConfig{terminal, {Name: "C", Tracks: 2, Headway: &testTransit}, terminal}, []SectionConfig{{Transit: testTransit}, {Transit: testTransit}}},
+		{[]StationConfig{terminal, loop, terminal}, []SectionConfig{{Transit: testTransit, Blocks: -1}, {Transit: testTransit}}},
+		{[]StationConfig{terminal, terminal}, []SectionConfig{{Transit: DistributionConfig{"uniform", []float64{18, 12}}}}},
+		{[]StationConfig{terminal, terminal}, []SectionConfig{{Profile: &ProfileConfig{}}}},
+	}
+
+	for i, line := range tests {
+		if _, err := BuildLine(line, nil, nil); err == nil {
+			t.Errorf("Test %d: expected error of line %+v", i, line)
+		}
+	}
+}
+
+func TestLineOccupancy(t *testing.T) {
+	busy := DistributionConfig{"uniform", []float64{15, 35}}
+	// Trains cross on both loops, follow each other in blocks and run on double track.
+	line := LineConfig{
+		[]StationConfig{{Name: "A", Tracks: 1, Headway: &busy}, {Name: "C", Tracks: 2}, {Name: "D", Tracks: 3}, {Name: "B", Tracks: 1, Headway: &busy}},
+		[]SectionConfig{{Transit: testTransit}, {Transit: testTransit, Blocks: 3}, {Transit: testTransit, Double: true}},
+	}
+	for seed := int64(1); seed <= 20; seed++ {
+		M := testModel(t, &line, 48)
+		S := simulate(M, FIFODispatcher{}, seed)
+		checkOccupancy(t, M, S)
+		if n := trains(M); n < 100 {
+			t.Errorf("Expected more than 100 trains in 48 hours, got %d", n)
+		}
+	}
+}
+
+func TestHeadway(t *testing.T) {
+	frequent := DistributionConfig{"uniform", []float64{1, 3}}
+	tests := []struct {
+		headway, low, high float64
+	}{
+		// Without headway following trains enter section as soon as the first block is free.
+		{0, 0, 10},
+		{10, 10, 15},
+		{25, 25, 30},
+	}
+
+	for _, test := range tests {
+		line := LineConfig{
+			[]StationConfig{{Name: "A", Tracks: 1, Headway: &frequent}, {Name: "B", Tracks: 1, Headway: &frequent}},
+			[]SectionConfig{{Transit: testTransit, Double: true, Blocks: 3, Headway: test.headway}},
+		}
+		M := testModel(t, &line, 24)
+		S := simulate(M, FIFODispatcher{}, 1)
+		checkOccupancy(t, M, S)
+
+		var entries []float64
+		for _, movement := range M.Journal.Movements {
+			if movement.From == M.Stations[0].Tracks[0] && movement.To == M.Sections[0].Points[0] {
+				entries = append(entries, movement.Time)
+			}
+		}
+		minimum := entries[1] - entries[0]
+		for i := 2; i < len(entries); i++ {
+			if gap := entries[i] - entries[i-1]; gap < minimum {
+				minimum = gap
+			}
+		}
+		if minimum < test.low-1e-9 || minimum >= test.high {
+			t.Errorf("Expected minimal interval between trains entering section with headway %.0f in [%.0f, %.0f), got %.2f",
+				test.headway, test.low, test.high, minimum)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"simulation-modeling/sim"
+	"strings"
+)
+
+// Station of simulation model with points of its tracks.
+// Trains arrive at terminal station by its headway timing with its priority.
+type ModelStation struct {
+	Name     string
+	Tracks   []int
+	Terminal bool
+	Headway  int
+	Priority int
+}
+
+// Section of simulation model between neighbouring stations with points of its blocks.
+// Blocks is number of physical blocks, single-track block has separate point for each direction.
+// Running times by direction are set if transit is computed by motion of train.
+type ModelSection struct {
+	Name    string
+	Points  []int
+	Blocks  int
+	Running [2]Running
+}
+
+// Simulation model: points, timings, checks and transitions.
+// Stations are ordered along the line, section i is between stations i and i+1.
+// Statistic values of points are kept for batch means if KeepValues is set.
+type Model struct {
+	Title       string
+	Points      int
+	Clock       int
+	TimeTable   map[int]sim.Distribution
+	CheckTable  map[sim.Points][]int
+	RoadMap     map[Checks][]Action
+	Timetable   *Timetable
+	Energy      *Energy
+	Fleet       *Fleet
+	Passengers  *Passengers
+	Journal     *Journal
+	Animation   *Animation
+	Checkpoints *Checkpoints
+	Lengths     map[int]float64
+	KeepValues  bool
+	Stations    []ModelStation
+	Sections    []ModelSection
+	directions  map[int]int
+	// Time when train is ready to leave its current waypoint: end of its transit or arrival at station.
+	ready map[int]float64
+}
+
+// Terminal returns point of terminal station by case-insensitive name.
+func (M *Model) Terminal(Name string) (int, bool) {
+	for _, station := range M.Stations {
+		if station.Terminal && strings.EqualFold(station.Name, Name) {
+			return station.Tracks[0], true
+		}
+	}
+	return 0, false
+}
+
+// StationByName returns index of station by case-insensitive name.
+func (M *Model) StationByName(Name string) (int, bool) {
+	for i, station := range M.Stations {
+		if strings.EqualFold(station.Name, Name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Heading returns first point of section which transaction is heading to by its waypoints
+// and direction of movement: 0 from first station, 1 from last station.
+// Point0 is returned if transaction isn't heading to section.
+func (M *Model) Heading(P sim.Points) (int, int) {
+	for step := 0; step < 3; step++ {
+		if j, ok := M.sectionIndex(P.Next); ok {
+			if direction, ok := M.directions[P.Next]; ok {
+				return M.Sections[j].Points[0], direction
+			}
+			if i, ok := M.stationIndex(P.Current); ok && i > j {
+				return M.Sections[j].Points[0], 1
+			}
+			return M.Sections[j].Points[0], 0
+		}
+		next, ok := M.Upcoming(P)
+		if !ok {
+			break
+		}
+		P = next
+	}
+	return Point0, 0
+}
+
+// Standing returns time for which transaction stands on its current waypoint after it was ready to leave it:
+// waiting for points, dwell or timetable hold.
+func (M *Model) Standing(S *sim.Sim, Tr *sim.Transaction) float64 {
+	ready, ok := M.ready[sim.GetId(*Tr)]
+	if !ok {
+		ready = sim.GetTime(*Tr)
+	}
+	return S.GetSimTime() - ready
+}
+
+// Occupation returns mean time of next movement of transaction by its waypoints.
+func (M *Model) Occupation(P sim.Points) float64 {
+	for step := 0; step < 3; step++ {
+		for _, action := range M.RoadMap[Checks{P.Current, P.Next, true}] {
+			if action.Type == Use && action.Arguments[0] != 0 {
+				return M.TimeTable[action.Arguments[0]].Mean()
+			}
+		}
+		next, ok := M.Upcoming(P)
+		if !ok {
+			break
+		}
+		P = next
+	}
+	return 0.0
+}
+
+// Upcoming returns waypoints of transaction after its next movement if checks pass.
+func (M *Model) Upcoming(P sim.Points) (sim.Points, bool) {
+	for _, action := range M.RoadMap[Checks{P.Current, P.Next, true}] {
+		if action.Type == Use {
+			return sim.Points{P.Next, action.Arguments[1]}, true
+		}
+	}
+	return P, false
+}
+
+func (M *Model) stationIndex(Point int) (int, bool) {
+	for i, station := range M.Stations {
+		for _, track := range station.Tracks {
+			if track == Point {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (M *Model) sectionIndex(Point int) (int, bool) {
+	for i, section := range M.Sections {
+		for _, point := range section.Points {
+			if point == Point {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// CrossingLoop returns model of A-C-B railway with crossing loop C.
+func CrossingLoop(TimeTable map[int]sim.Distribution) *Model {
+	checks := map[sim.Points][]int{
+		sim.Points{Point0, PointA}:   []int{PointAC, PointCm, PointCr},
+		sim.Points{Point0, PointB}:   []int{PointBC, PointCm, PointCr},
+		sim.Points{PointA, PointAC}:  []int{PointBC},
+		sim.Points{PointCr, PointBC}: []int{PointBC},
+		sim.Points{PointCm, PointBC}: []int{PointBC},
+		sim.Points{PointB, PointCr}:  []int{PointAC},
+		sim.Points{PointCr, PointAC}: []int{PointAC},
+		sim.Points{PointCm, PointAC}: []int{PointAC},
+	}
+
+	transfers := map[Checks][]Action{
+		{Point0, PointA, false}:   []Action{Action{Wait, []int{}}, Action{Generate, []int{Station, PointA}}},          // >A****C****B
+		{Point0, PointA, true}:    []Action{Action{Use, []int{0, PointAC}}, Action{Generate, []int{Station, PointA}}}, // >A****C****B
+		{PointA, PointAC, false}:  []Action{Action{Use, []int{AC, PointCr, PointCm}}},                                 // A>***Cr****B
+		{PointA, PointAC, true}:   []Action{Action{Use, []int{AC, PointCm, PointCr}}},                                 // A>***Cm****B
+		{PointAC, PointCm, true}:  []Action{Action{Use, []int{0, PointBC}}},                                           //
+		{PointAC, PointCr, true}:  []Action{Action{Use, []int{0, PointBC}}},                                           //
+		{PointCm, PointBC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cm<***B
+		{PointCm, PointBC, true}:  []Action{Action{Use, []int{BC, PointB}}},                                           // A****Cm>***B
+		{PointCr, PointBC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cr<***B
+		{PointCr, PointBC, true}:  []Action{Action{Use, []int{BC, PointB}}},                                           // A****Cr>***B
+		{PointBC, PointB, true}:   []Action{Action{Use, []int{0, Point0}}},                                            // A****C***->B
+
+		{Point0, PointB, false}:   []Action{Action{Wait, []int{}}, Action{Generate, []int{Station, PointB}}},          // A****C****B<
+		{Point0, PointB, true}:    []Action{Action{Use, []int{0, PointBC}}, Action{Generate, []int{Station, PointB}}}, // A****C****B<
+		{PointB, PointBC, false}:  []Action{Action{Use, []int{CB, PointCr, PointCm}}},                                 // A****Cr***<B
+		{PointB, PointBC, true}:   []Action{Action{Use, []int{CB, PointCm, PointCr}}},                                 // A****Cm***<B
+		{PointBC, PointCm, true}:  []Action{Action{Use, []int{0, PointAC}}},                                           //
+		{PointBC, PointCr, true}:  []Action{Action{Use, []int{0, PointAC}}},                                           //
+		{PointCm, PointAC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cm<***B
+		{PointCm, PointAC, true}:  []Action{Action{Use, []int{CA, PointA}}},                                           // A****Cm>***B
+		{PointCr, PointAC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cr<***B
+		{PointCr, PointAC, true}:  []Action{Action{Use, []int{CA, PointA}}},                                           // A****Cr>***B
+		{PointAC, PointA, true}:   []Action{Action{Use, []int{0, Point0}}},                                            // A<-***C****B
+
+		{Point0, ClockPoint, true}: []Action{Action{Terminate, []int{}}}, // Clock
+	}
+
+	return &Model{Title: "Crossing loop", Points: Points, Clock: ClockPoint, TimeTable: TimeTable, CheckTable: checks, RoadMap: transfers,
+		Stations: []ModelStation{
+			{"A", []int{PointA}, true, Station, 0},
+			{"C", []int{PointCm, PointCr}, false, 0, 0},
+			{"B", []int{PointB}, true, Station, 0}},
+		Sections: []ModelSection{{"AC", []int{PointAC}, 1, [2]Running{}}, {"BC", []int{PointBC}, 1, [2]Running{}}}}
+}
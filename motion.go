@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"simulation-modeling/sim"
+)
+
+const gravity = 9.81
+
+// Step of numerical integration of train motion, m.
+const motionStep = 1.0
+
+// Train in configuration: mass in tonnes, traction curve as pairs of speed in km/h and force in kN,
+// Davis resistance coefficients A + B*v + C*v*v in kN with speed in km/h and braking deceleration in m/s^2.
+// Traction force is interpolated linearly between points of curve and kept constant beyond them.
+type TrainConfig struct {
+	Mass       float64      `json:"mass"`
+	MassFactor float64      `json:"mass_factor,omitempty"`
+	Traction   [][2]float64 `json:"traction"`
+	Resistance [3]float64   `json:"resistance"`
+	Braking    float64      `json:"braking"`
+}
+
+// Segment of section with length in m, gradient in per mille (positive uphill) and speed limit in km/h.
+type SegmentConfig struct {
+	Length     float64 `json:"length"`
+	Gradient   float64 `json:"gradient,omitempty"`
+	SpeedLimit float64 `json:"speed_limit"`
+}
+
+// Track profile of section from its first station to next one.
+// Running time is computed by motion of train, perturbation is added to it.
+type ProfileConfig struct {
+	Segments     []SegmentConfig     `json:"segments"`
+	Perturbation *DistributionConfig `json:"perturbation,omitempty"`
+}
+
+// Running time of section in minutes: train stops at terminal stations and runs through passing loops.
+// Start and stop penalties are added to it if train starts from stop or stops at passing loop.
+type Running struct {
+	Time, Start, Stop float64
+}
+
+// traction returns traction force in kN by speed in m/s.
+func (T TrainConfig) traction(v float64) float64 {
+	speed, curve := v*3.6, T.Traction
+	if speed <= curve[0][0] {
+		return curve[0][1]
+	}
+	for i := 1; i < len(curve); i++ {
+		if speed <= curve[i][0] {
+			return curve[i-1][1] + (curve[i][1]-curve[i-1][1])*(speed-curve[i-1][0])/(curve[i][0]-curve[i-1][0])
+		}
+	}
+	return curve[len(curve)-1][1]
+}
+
+// resistance returns running resistance in kN by speed in m/s.
+func (T TrainConfig) resistance(v float64) float64 {
+	speed := v * 3.6
+	return T.Resistance[0] + T.Resistance[1]*speed + T.Resistance[2]*speed*speed
+}
+
+func (T TrainConfig) validate() error {
+	if T.Mass <= 0 || T.Braking <= 0 || T.MassFactor < 0 {
+		return errors.New("train must have positive mass and braking")
+	}
+	if len(T.Traction) == 0 {
+		return errors.New("train must have traction curve")
+	}
+	for i := 1; i < len(T.Traction); i++ {
+		if T.Traction[i][0] <= T.Traction[i-1][0] {
+			return errors.New("speeds of traction curve must increase")
+		}
+	}
+	return nil
+}
+
+// RunningTime returns minimum time in minutes to run over segments.
+// Train starts from stop if Start is true and stops at end of segments if Stop is true,
+// otherwise it runs through ends at allowed speed.
+func (T TrainConfig) RunningTime(Segments []SegmentConfig, Start, Stop bool) (float64, error) {
+	if err := T.validate(); err != nil {
+		return 0, err
+	}
+	mass := T.Mass
+	if T.MassFactor > 0 {
+		mass *= T.MassFactor
+	}
+	// Speed limits in m/s and gradients by steps.
+	var limits, gradients []float64
+	for _, segment := range Segments {
+		if segment.Length <= 0 || segment.SpeedLimit <= 0 {
+			return 0, errors.New("segment must have positive length and speed limit")
+		}
+		steps := int(math.Ceil(segment.Length / motionStep))
+		for i := 0; i < steps; i++ {
+			limits = append(limits, segment.SpeedLimit/3.6)
+			gradients = append(gradients, segment.Gradient)
+		}
+	}
+	n := len(limits)
+	if n == 0 {
+		return 0, errors.New("profile must have segments")
+	}
+	// Speed limit of node between steps.
+	limit := func(i int) float64 {
+		switch {
+		case i == 0:
+			return limits[0]
+		case i == n:
+			return limits[n-1]
+		}
+		return math.Min(limits[i-1], limits[i])
+	}
+
+	// Braking curve from end of segments and lower speed limits.
+	braking := make([]float64, n+1)
+	braking[n] = limit(n)
+	if Stop {
+		braking[n] = 0
+	}
+	for i := n - 1; i >= 0; i-- {
+		deceleration := T.Braking + gravity*gradients[i]/1000
+		if deceleration <= 0 {
+			return 0, errors.New(fmt.Sprintf("train can't brake on gradient %.1f", gradients[i]))
+		}
+		braking[i] = math.Min(limit(i), math.Sqrt(braking[i+1]*braking[i+1]+2*deceleration*motionStep))
+	}
+
+	v, time := braking[0], 0.0
+	if Start {
+		v = 0
+	}
+	for i := 0; i < n; i++ {
+		acceleration := (T.traction(v)-T.resistance(v))/mass - gravity*gradients[i]/1000
+		square := v*v + 2*acceleration*motionStep
+		if square <= 0 {
+			return 0, errors.New(fmt.Sprintf("train stalls at %.0f m", float64(i)*motionStep))
+		}
+		next := math.Min(math.Sqrt(square), braking[i+1])
+		if v+next <= 0 {
+			return 0, errors.New("profile is too short")
+		}
+		time += 2 * motionStep / (v + next)
+		v = next
+	}
+	return time / 60, nil
+}
+
+// Run returns running time and penalties of train over profile in direction from its first segment (0)
+// or from its last segment (1), gradients are reversed for the latter.
+// Terminal tells if stations at first and last segment of profile are terminal.
+func (T TrainConfig) Run(P ProfileConfig, Direction int, Terminal [2]bool) (Running, error) {
+	segments, start, stop := P.Segments, Terminal[0], Terminal[1]
+	if Direction == 1 {
+		segments, start, stop = make([]SegmentConfig, len(P.Segments)), Terminal[1], Terminal[0]
+		for i, segment := range P.Segments {
+			segment.Gradient = -segment.Gradient
+			segments[len(segments)-1-i] = segment
+		}
+	}
+	time, err := T.RunningTime(segments, start, stop)
+	if err != nil {
+		return Running{}, err
+	}
+	running := Running{Time: time}
+	if !start {
+		stopped, err := T.RunningTime(segments, true, stop)
+		if err != nil {
+			return Running{}, err
+		}
+		running.Start = stopped - time
+	}
+	if !stop {
+		stopped, err := T.RunningTime(segments, start, true)
+		if err != nil {
+			return Running{}, err
+		}
+		running.Stop = stopped - time
+	}
+	return running, nil
+}
+
+// Penalty returns time lost by train which departs by its waypoints from passing loop after stop:
+// stop penalty of section it arrived by and start penalty of section it departs to.
+func (M *Model) Penalty(P sim.Points) float64 {
+	station, ok := M.stationIndex(P.Current)
+	if !ok || M.Stations[station].Terminal {
+		return 0
+	}
+	section, direction := M.Heading(P)
+	j, ok := M.sectionIndex(section)
+	if !ok {
+		return 0
+	}
+	penalty, arrived := M.Sections[j].Running[direction].Start, j-1
+	if direction == 1 {
+		arrived = j + 1
+	}
+	if arrived >= 0 && arrived < len(M.Sections) {
+		penalty += M.Sections[arrived].Running[direction].Stop
+	}
+	return penalty
+}
+
+// Timing returns distribution of running time: computed time with perturbation added.
+func (P ProfileConfig) Timing(Time float64) (sim.Distribution, error) {
+	if P.Perturbation == nil {
+		return sim.Pair{Time, Time}, nil
+	}
+	perturbation, err := sim.NewDistribution(P.Perturbation.Distribution, P.Perturbation.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	return sim.Shifted{perturbation, Time}, nil
+}
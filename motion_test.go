@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"simulation-modeling/sim"
+	"testing"
+)
+
+// Train with huge traction and without resistance reaches speed limit at once, it only brakes for 40 s from 72 km/h.
+var testTrain = TrainConfig{Mass: 1, Traction: [][2]float64{{0, 1000}}, Braking: 0.5}
+
+var testFreight = TrainConfig{Mass: 1200, MassFactor: 1.06, Traction: [][2]float64{{0, 300}, {40, 300}, {120, 100}},
+	Resistance: [3]float64{12, 0.1, 0.004}, Braking: 0.5}
+
+func TestRun(t *testing.T) {
+	flat := ProfileConfig{Segments: []SegmentConfig{{Length: 1000, SpeedLimit: 72}}}
+	limited := ProfileConfig{Segments: []SegmentConfig{{Length: 500, SpeedLimit: 72}, {Length: 500, SpeedLimit: 36}}}
+	tests := []struct {
+		profile   ProfileConfig
+		direction int
+		terminal  [2]bool
+		running   Running
+	}{
+		// 50 s at 72 km/h and 20 s lost by braking to stop.
+		{flat, 0, [2]bool{true, true}, Running{70.0 / 60, 0, 0}},
+		{flat, 0, [2]bool{false, false}, Running{50.0 / 60, 0, 20.0 / 60}},
+		// Backward train starts at last segment and stops at first one.
+		{flat, 1, [2]bool{true, false}, Running{70.0 / 60, 0, 0}},
+		{flat, 1, [2]bool{false, true}, Running{50.0 / 60, 0, 20.0 / 60}},
+		// 200 m at 72 km/h, 300 m braking to 36 km/h for 20 s and 500 m at 36 km/h.
+		{limited, 0, [2]bool{false, false}, Running{80.0 / 60, 0, 10.0 / 60}},
+		// Train accelerates at once after lower speed limit.
+		{limited, 1, [2]bool{false, false}, Running{75.0 / 60, 0, 20.0 / 60}},
+	}
+
+	for i, test := range tests {
+		running, err := testTrain.Run(test.profile, test.direction, test.terminal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(running.Time-test.running.Time) > 0.01 || math.Abs(running.Start-test.running.Start) > 0.01 ||
+			math.Abs(running.Stop-test.running.Stop) > 0.01 {
+			t.Errorf("Test %d: expected running %+v, got %+v", i, test.running, running)
+		}
+	}
+}
+
+func TestRunGradient(t *testing.T) {
+	flat := ProfileConfig{Segments: []SegmentConfig{{Length: 6000, SpeedLimit: 100}}}
+	uphill := ProfileConfig{Segments: []SegmentConfig{{Length: 6000, Gradient: 5, SpeedLimit: 100}}}
+	// Trains start from terminal and run through passing loop.
+	forward, backward := [2]bool{true, false}, [2]bool{false, true}
+	level, _ := testFreight.Run(flat, 0, forward)
+	levelBack, _ := testFreight.Run(flat, 1, backward)
+	up, _ := testFreight.Run(uphill, 0, forward)
+	down, _ := testFreight.Run(uphill, 1, backward)
+	if up.Time <= level.Time || down.Time >= levelBack.Time {
+		t.Errorf("Expected running time uphill %.2f greater than %.2f on flat and downhill %.2f less than %.2f on flat",
+			up.Time, level.Time, down.Time, levelBack.Time)
+	}
+	// Train from passing loop to terminal only loses time by start from stop.
+	fromLoop, _ := testFreight.Run(uphill, 1, forward)
+	if up.Start != 0 || up.Stop <= 0 || fromLoop.Start <= 0 || fromLoop.Stop != 0 {
+		t.Errorf("Expected only stop penalty uphill to loop and start penalty downhill from loop, got %+v and %+v", up, fromLoop)
+	}
+
+	tests := []ProfileConfig{
+		{},
+		{Segments: []SegmentConfig{{Length: 1000}}},
+		{Segments: []SegmentConfig{{Length: 1000, Gradient: 40, SpeedLimit: 100}}},
+		{Segments: []SegmentConfig{{Length: 1000, Gradient: -60, SpeedLimit: 100}}},
+	}
+	for i, profile := range tests {
+		if _, err := testFreight.Run(profile, 0, [2]bool{true, true}); err == nil {
+			t.Errorf("Test %d: expected error of profile %+v", i, profile)
+		}
+	}
+}
+
+func TestPenalty(t *testing.T) {
+	M := testModel(t, nil, 24)
+	M.Sections[0].Running = [2]Running{{10, 0, 1}, {10, 0.5, 0}}
+	M.Sections[1].Running = [2]Running{{12, 0.7, 0}, {12, 0, 0.4}}
+	tests := []struct {
+		points  sim.Points
+		penalty float64
+	}{
+		{sim.Points{PointCm, PointBC}, 1.7},
+		{sim.Points{PointCr, PointAC}, 0.9},
+		{sim.Points{PointA, PointAC}, 0},
+		{sim.Points{PointAC, PointCm}, 0},
+	}
+
+	for _, test := range tests {
+		if penalty := M.Penalty(test.points); math.Abs(penalty-test.penalty) > 1e-9 {
+			t.Errorf("Expected penalty %.1f of departure %v, got %.2f", test.penalty, test.points, penalty)
+		}
+	}
+}
+
+func TestStopPenalty(t *testing.T) {
+	config := DefaultConfig()
+	config.Train = &testFreight
+	profile := ProfileConfig{Segments: []SegmentConfig{{Length: 6000, Gradient: 5, SpeedLimit: 100}, {Length: 8000, SpeedLimit: 60}}}
+	config.Profiles = map[string]ProfileConfig{"ac": profile, "bc": profile}
+	M, err := config.Model(48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M.KeepValues = true
+	S := simulate(M, FIFODispatcher{}, 1)
+
+	// Running times are exact without perturbation, train which stood on loop departs with penalty.
+	stopped := 0
+	for j, section := range M.Sections {
+		running := section.Running
+		// Trains depart from loop to AC backward and to BC forward.
+		departure := [2]int{1, 0}[j]
+		penalty := M.Penalty(sim.Points{PointCm, section.Points[0]})
+		if penalty <= 0 {
+			t.Errorf("Expected penalty of departure from loop to %s, got %.2f", section.Name, penalty)
+		}
+		values, err := S.GetValues(section.Points[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range values {
+			switch {
+			case math.Abs(value-running[departure].Time-penalty) < 1e-6:
+				stopped++
+			case math.Abs(value-running[0].Time) > 1e-6 && math.Abs(value-running[1].Time) > 1e-6:
+				t.Errorf("Expected running time of %s with or without penalty %.2f, got %.2f", section.Name, penalty, value)
+			}
+		}
+	}
+	if stopped == 0 {
+		t.Errorf("Expected trains stopped on loop")
+	}
+}
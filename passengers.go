@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"simulation-modeling/sim"
+	"simulation-modeling/statistic"
+)
+
+// Passenger demand in configuration.
+// Passengers arrive at station as Poisson process by rate per minute in each direction and travel
+// to station chosen uniformly among next stations. Boarding and alighting are in seconds per passenger,
+// dwell is minimum dwell time in minutes.
+type PassengerConfig struct {
+	Arrivals  map[string]float64 `json:"arrivals"`
+	Boarding  float64            `json:"boarding"`
+	Alighting float64            `json:"alighting"`
+	Capacity  int                `json:"capacity"`
+	Dwell     float64            `json:"dwell,omitempty"`
+}
+
+// Passengers of simulation run: queues of passengers by stations and directions and loads of trains.
+// Train dwells on station before it departs to section until passengers alight and board.
+type Passengers struct {
+	Config                        PassengerConfig
+	rates                         []float64
+	queues                        [][2][]float64
+	next                          [][2]float64
+	loads                         map[int]map[int]int
+	dwelled                       map[int]int
+	dwells                        [][]float64
+	boarded, alighted, leftBehind int
+	waiting                       float64
+}
+
+func NewPassengers(Config PassengerConfig, M *Model) (*Passengers, error) {
+	if Config.Capacity < 1 || Config.Boarding < 0 || Config.Alighting < 0 || Config.Dwell < 0 {
+		return nil, errors.New("passengers must have positive train capacity and non-negative times")
+	}
+	P := &Passengers{Config: Config, rates: make([]float64, len(M.Stations))}
+	for name, rate := range Config.Arrivals {
+		i, ok := M.StationByName(name)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("unknown station in passenger arrivals: %q", name))
+		}
+		if rate < 0 {
+			return nil, errors.New(fmt.Sprintf("negative passenger arrival rate on station %s", name))
+		}
+		P.rates[i] = rate
+	}
+	P.Reset(M)
+	return P, nil
+}
+
+// Reset empties stations and trains before simulation run.
+func (P *Passengers) Reset(M *Model) {
+	P.queues, P.next = make([][2][]float64, len(M.Stations)), make([][2]float64, len(M.Stations))
+	for i := range P.next {
+		P.next[i] = [2]float64{-1, -1}
+	}
+	P.loads, P.dwelled = make(map[int]map[int]int), make(map[int]int)
+	P.dwells = make([][]float64, len(M.Stations))
+	P.boarded, P.alighted, P.leftBehind, P.waiting = 0, 0, 0, 0
+}
+
+// destinations returns indices of stations after station in direction.
+func destinations(M *Model, Station, Direction int) []int {
+	var result []int
+	if Direction == 0 {
+		for i := Station + 1; i < len(M.Stations); i++ {
+			result = append(result, i)
+		}
+	} else {
+		for i := Station - 1; i >= 0; i-- {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// arrive adds passengers arrived at station in direction until time.
+func (P *Passengers) arrive(S *sim.Sim, R sim.Source, Station, Direction int, Time float64) {
+	if P.rates[Station] == 0 {
+		return
+	}
+	next := &P.next[Station][Direction]
+	for *next < 0 || *next <= Time {
+		if *next >= 0 {
+			P.queues[Station][Direction] = append(P.queues[Station][Direction], *next)
+		}
+		interval, err := sim.Exponential(R, 1/P.rates[Station])
+		if err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+		*next = math.Max(0, *next) + interval
+	}
+}
+
+// alight removes passengers of transaction travelling to station, it returns their number.
+func (P *Passengers) alight(Tr *sim.Transaction, Station int) int {
+	load := P.loads[sim.GetId(*Tr)]
+	alighted := load[Station]
+	delete(load, Station)
+	P.alighted += alighted
+	return alighted
+}
+
+// Dwell alights and boards passengers of transaction departing from station to section and holds it for dwell time.
+// It returns true if transaction is returned to future event chain.
+func (P *Passengers) Dwell(S *sim.Sim, R sim.Source, M *Model, Tr *sim.Transaction) bool {
+	points, id := sim.GetPoints(*Tr), sim.GetId(*Tr)
+	station, ok := M.stationIndex(points.Current)
+	if _, next := M.sectionIndex(points.Next); !ok || !next || P.dwelled[id] == station+1 {
+		return false
+	}
+	P.dwelled[id] = station + 1
+	_, direction := M.Heading(points)
+	if P.loads[id] == nil {
+		P.loads[id] = make(map[int]int)
+	}
+	alighted := P.alight(Tr, station)
+
+	P.arrive(S, R, station, direction, S.GetSimTime())
+	queue, load := P.queues[station][direction], 0
+	for _, count := range P.loads[id] {
+		load += count
+	}
+	boarded := len(queue)
+	if free := P.Config.Capacity - load; boarded > free {
+		boarded = free
+	}
+	stations := destinations(M, station, direction)
+	for _, arrival := range queue[:boarded] {
+		P.waiting += S.GetSimTime() - arrival
+		P.loads[id][stations[int(R.Float64()*float64(len(stations)))]]++
+	}
+	P.queues[station][direction] = queue[boarded:]
+	P.boarded += boarded
+	P.leftBehind += len(queue) - boarded
+
+	dwell := P.Config.Dwell + (float64(alighted)*P.Config.Alighting+float64(boarded)*P.Config.Boarding)/60
+	P.dwells[station] = append(P.dwells[station], dwell)
+	if dwell <= 0 {
+		return false
+	}
+	if err := S.Delay(Tr, dwell); err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	return true
+}
+
+// Arrive alights all passengers of transaction arrived at terminal station.
+func (P *Passengers) Arrive(M *Model, Tr *sim.Transaction) {
+	points, id := sim.GetPoints(*Tr), sim.GetId(*Tr)
+	if _, ok := P.loads[id]; !ok || points.Next != Point0 {
+		return
+	}
+	if station, ok := M.stationIndex(points.Current); ok {
+		P.alight(Tr, station)
+	}
+	delete(P.loads, id)
+	delete(P.dwelled, id)
+}
+
+func WritePassengerReport(Writer *bufio.Writer, P *Passengers, M *Model) {
+	waiting := 0.0
+	if P.boarded != 0 {
+		waiting = P.waiting / float64(P.boarded)
+	}
+	WriteData(Writer, fmt.Sprintf("Passengers boarded: %d, alighted: %d, left behind: %d\n", P.boarded, P.alighted, P.leftBehind))
+	WriteData(Writer, fmt.Sprintf("Mean passenger waiting time: %.2f\n", waiting))
+	for i, station := range M.Stations {
+		if len(P.dwells[i]) != 0 {
+			WriteData(Writer, fmt.Sprintf("Dwell time on %s: mean %.2f, standard deviation %.2f (%d stops)\n", StationLabel(station),
+				statistic.Mean(P.dwells[i]), math.Sqrt(statistic.Variance(P.dwells[i])), len(P.dwells[i])))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewPassengers(t *testing.T) {
+	M := testModel(t, nil, 24)
+	tests := []struct {
+		config PassengerConfig
+		valid  bool
+	}{
+		{PassengerConfig{Arrivals: map[string]float64{"A": 0.5, "c": 0.1}, Boarding: 3, Alighting: 2, Capacity: 100}, true},
+		{PassengerConfig{Arrivals: map[string]float64{"A": 0.5}, Capacity: 0}, false},
+		{PassengerConfig{Arrivals: map[string]float64{"X": 0.5}, Capacity: 100}, false},
+		{PassengerConfig{Arrivals: map[string]float64{"A": -0.5}, Capacity: 100}, false},
+		{PassengerConfig{Boarding: -1, Capacity: 100}, false},
+	}
+
+	for i, test := range tests {
+		if _, err := NewPassengers(test.config, M); (err == nil) != test.valid {
+			t.Errorf("Test %d: expected validity %t of configuration, got error %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	M := testModel(t, nil, 24)
+	tests := []struct {
+		station, direction int
+		destinations       []int
+	}{
+		{0, 0, []int{1, 2}},
+		{1, 0, []int{2}},
+		{1, 1, []int{0}},
+		{2, 1, []int{1, 0}},
+		{2, 0, nil},
+	}
+
+	for _, test := range tests {
+		if result := destinations(M, test.station, test.direction); !reflect.DeepEqual(result, test.destinations) {
+			t.Errorf("Expected destinations %v of station %d in direction %d, got %v", test.destinations, test.station, test.direction, result)
+		}
+	}
+}
+
+func TestPassengerRun(t *testing.T) {
+	M := testModel(t, nil, 24)
+	config := PassengerConfig{Arrivals: map[string]float64{"A": 1, "C": 0.2, "B": 0.2}, Boarding: 3, Alighting: 2, Capacity: 30, Dwell: 1}
+	P, err := NewPassengers(config, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M.Passengers = P
+	simulate(M, FIFODispatcher{}, 1)
+
+	// Trains from A are filled up, passengers alight only from trains which arrived.
+	if P.leftBehind == 0 || P.boarded == 0 || P.alighted > P.boarded {
+		t.Errorf("Expected passengers left behind on A and alighted not more than boarded, got %d boarded, %d alighted, %d left behind",
+			P.boarded, P.alighted, P.leftBehind)
+	}
+	maximum := config.Dwell + float64(config.Capacity)*(config.Boarding+config.Alighting)/60
+	for i, dwells := range P.dwells {
+		if len(dwells) == 0 {
+			t.Errorf("Expected dwells on station %s", M.Stations[i].Name)
+		}
+		for _, dwell := range dwells {
+			if dwell < config.Dwell || dwell > maximum {
+				t.Errorf("Expected dwell on station %s between %.1f and %.1f, got %.2f", M.Stations[i].Name, config.Dwell, maximum, dwell)
+			}
+		}
+	}
+	for id, load := range P.loads {
+		total := 0
+		for _, count := range load {
+			total += count
+		}
+		if total > config.Capacity {
+			t.Errorf("Expected load of train %d not greater than capacity, got %d", id, total)
+		}
+	}
+}
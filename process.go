@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"simulation-modeling/sim"
+)
+
+// Crossing loop written as processes of trains. Routes are set by the same checks as in table model,
+// so results are the same as results of CrossingLoop with the same random stream.
+type processLoop struct {
+	S   *sim.Sim
+	R   sim.Source
+	M   *Model
+	D   Dispatcher
+	env *sim.Env
+}
+
+func (L *processLoop) fail(err error) {
+	if err != nil {
+		fmt.Println(err, L.S.DebugString())
+		os.Exit(1)
+	}
+}
+
+func (L *processLoop) sample(Timing int) float64 {
+	time, err := L.M.TimeTable[Timing].Sample(L.R)
+	L.fail(err)
+	return time
+}
+
+// free returns true if points checked for movement of train between waypoints are free.
+func (L *processLoop) free(Tr *sim.Transaction, Current, Next int) bool {
+	free, err := CheckPoints(L.S, Tr, L.M.CheckTable[sim.Points{Current, Next}])
+	L.fail(err)
+	return free
+}
+
+// arrival creates next train arriving at terminal station.
+func (L *processLoop) arrival(Origin, Near, Far, Destination, NearTiming, FarTiming int) {
+	station, _ := L.M.stationIndex(Origin)
+	time := L.sample(L.M.Stations[station].Headway)
+	p, err := L.env.Spawn(time, Origin, L.M.Stations[station].Priority, func(p *sim.Process) {
+		L.train(p, Origin, Near, Far, Destination, NearTiming, FarTiming)
+	})
+	L.fail(err)
+	SetLength(L.S, L.R, p.Tr, L.M.TimeTable[Length])
+	L.S.AddStatistic(Point0, time)
+}
+
+// move releases current waypoint of train, seizes point and heads train to next waypoint.
+func (L *processLoop) move(p *sim.Process, Current, Point, Next int) {
+	L.D.Dispatched(L.M, p.Tr)
+	L.fail(p.Release(Current))
+	L.fail(p.Seize(Point))
+	L.fail(p.Head(Next))
+}
+
+// train runs from origin through near section, track of loop and far section to destination.
+func (L *processLoop) train(p *sim.Process, Origin, Near, Far, Destination, NearTiming, FarTiming int) {
+	L.arrival(Origin, Near, Far, Destination, NearTiming, FarTiming)
+
+	// Train enters line when near section and loop are free.
+	JoinQueue(L.S, L.M, p.Tr)
+	waiting := p.WaitUntil(func() bool { return L.free(p.Tr, Point0, Origin) })
+	DepartQueue(L.S, L.M, p.Tr, sim.Points{Point0, Origin})
+	L.fail(p.Seize(Origin))
+	L.fail(p.Head(Near))
+	if waiting != 0 {
+		L.S.AddStatistic(Origin, waiting)
+	}
+	L.fail(p.Hold(0))
+
+	// Main track of loop is preferred if route beyond it is free.
+	track := 0
+	JoinQueue(L.S, L.M, p.Tr)
+	waiting = p.WaitUntil(func() bool {
+		tracks := []int{PointCm, PointCr}
+		if !L.free(p.Tr, Origin, Near) {
+			tracks = []int{PointCr, PointCm}
+		}
+		var ok bool
+		track, ok = Choose(L.S, p.Tr, tracks)
+		return ok
+	})
+	DepartQueue(L.S, L.M, p.Tr, sim.Points{Origin, Near})
+	time := L.sample(NearTiming)
+	L.move(p, Origin, Near, track)
+	L.S.AddStatistic(Near, time)
+	if waiting != 0 {
+		L.S.AddStatistic(Origin, waiting)
+	}
+	L.fail(p.Hold(time))
+
+	L.move(p, Near, track, Far)
+	L.fail(p.Hold(0))
+
+	// Train waits on loop until far section is free.
+	JoinQueue(L.S, L.M, p.Tr)
+	waiting = p.WaitUntil(func() bool { return L.free(p.Tr, track, Far) })
+	DepartQueue(L.S, L.M, p.Tr, sim.Points{track, Far})
+	time = L.sample(FarTiming)
+	L.move(p, track, Far, Destination)
+	L.S.AddStatistic(Far, time)
+	if waiting != 0 {
+		L.S.AddStatistic(track, waiting)
+	}
+	L.fail(p.Hold(time))
+
+	L.move(p, Far, Destination, Point0)
+	L.fail(p.Hold(0))
+}
+
+// SimulateProcesses runs crossing loop model written as processes of trains.
+func SimulateProcesses(R sim.Source, M *Model, D Dispatcher) *sim.Sim {
+	S := sim.New(M.Points)
+	S.Init()
+	if M.KeepValues {
+		S.KeepValues()
+	}
+	L := &processLoop{S, R, M, D, sim.NewEnv(S)}
+	L.env.Order = func(Waiting []*sim.Transaction) []*sim.Transaction { return D.Order(S, M, Waiting) }
+
+	_, err := L.env.Spawn(L.sample(Timer), M.Clock, 0, func(p *sim.Process) { S.Terminate() })
+	L.fail(err)
+	L.arrival(PointA, PointAC, PointBC, PointB, AC, BC)
+	L.arrival(PointB, PointBC, PointAC, PointA, CB, CA)
+	for !S.IsFinish() {
+		L.fail(L.env.Step())
+		if M.Animation != nil {
+			M.Animation.Frame(S, M)
+		}
+	}
+	L.env.Close()
+	return S
+}
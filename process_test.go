@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestSimulateProcesses(t *testing.T) {
+	points := []int{PointA, PointB, PointCm, PointCr, PointAC, PointBC}
+	for _, name := range []string{"fifo", "priority", "alternate", "lookahead"} {
+		for seed := int64(1); seed <= 10; seed++ {
+			M := testModel(t, nil, 48)
+			D, _ := NewDispatcher(name)
+			S := simulate(M, D, seed)
+
+			// Crossing loop written as processes gives the same results.
+			M = testModel(t, nil, 48)
+			D, _ = NewDispatcher(name)
+			P := SimulateProcesses(NewStream(seed, 0, false), M, D)
+			for _, point := range points {
+				mean, sum, _ := S.GetStatistic(point)
+				processMean, processSum, _ := P.GetStatistic(point)
+				if mean != processMean || sum != processSum {
+					t.Errorf("Expected the same statistic of point %d by %s dispatcher with seed %d, got %.2f, %.2f and %.2f, %.2f",
+						point, name, seed, mean, sum, processMean, processSum)
+				}
+			}
+		}
+	}
+}
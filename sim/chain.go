@@ -6,20 +6,25 @@ import (
 	"sort"
 )
 
-// Sorted event chain.
+// Sorted event chain. Transactions are indexed by id for lookup of pending events.
 type EventChain struct {
 	chain []*Transaction
 	name  string
+	index map[int]*Transaction
 }
 
 // New returns a new sorted event chain by specified name.
 // Slice of transactions has length 0 and capacity 20.
 func NewChain(name string) *EventChain {
-	return &EventChain{make([]*Transaction, 0, 20), name}
+	return &EventChain{make([]*Transaction, 0, 20), name, make(map[int]*Transaction)}
 }
 
 // Insert adds new transaction in sorted chain.
 func (ch *EventChain) Insert(tr *Transaction) error {
+	if _, ok := ch.index[tr.id]; ok {
+		return errors.New(fmt.Sprintf("transaction %d is already in chain", tr.id))
+	}
+	ch.index[tr.id] = tr
 	if ch.Len() == 0 {
 		ch.chain = append(ch.chain, tr)
 	} else {
@@ -81,5 +86,49 @@ func (ch *EventChain) GetHead() ([]*Transaction, error) {
 	if len(head) < 1 {
 		return nil, errors.New("no transaction in chain")
 	}
+	for _, tr := range head {
+		delete(ch.index, tr.id)
+	}
 	return head, nil
 }
+
+// Find returns pending transaction of chain by id.
+func (ch *EventChain) Find(id int) (*Transaction, bool) {
+	tr, ok := ch.index[id]
+	return tr, ok
+}
+
+// position returns index of transaction in chain, transactions before it are skipped by binary search.
+func (ch *EventChain) position(tr *Transaction) int {
+	for i := sort.Search(ch.Len(), func(i int) bool { return GetTime(*ch.chain[i]) >= GetTime(*tr) }); i < ch.Len(); i++ {
+		if ch.chain[i] == tr {
+			return i
+		}
+	}
+	return -1
+}
+
+// Cancel removes transaction from chain by id and returns it.
+func (ch *EventChain) Cancel(id int) (*Transaction, error) {
+	tr, ok := ch.index[id]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("no transaction %d in chain", id))
+	}
+	i := ch.position(tr)
+	if i < 0 {
+		return nil, errors.New(fmt.Sprintf("transaction %d isn't found by its time in chain", id))
+	}
+	ch.chain = append(ch.chain[:i], ch.chain[i+1:]...)
+	delete(ch.index, id)
+	return tr, nil
+}
+
+// Reschedule moves transaction to new time, it's placed before transactions with the same time.
+func (ch *EventChain) Reschedule(id int, newTime float64) error {
+	tr, err := ch.Cancel(id)
+	if err != nil {
+		return err
+	}
+	tr.Wait(newTime - tr.time)
+	return ch.Insert(tr)
+}
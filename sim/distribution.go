@@ -0,0 +1,315 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"simulation-modeling/statistic"
+)
+
+// Distribution of random variate.
+type Distribution interface {
+	// Sample returns random number by inverse transform of number from source.
+	Sample(r Source) (float64, error)
+	// Mean returns expected value.
+	Mean() float64
+	// CDF returns value of cumulative distribution function.
+	CDF(x float64) float64
+	// Name returns name of distribution.
+	Name() string
+	// Parameters returns parameters of distribution in the order accepted by NewDistribution.
+	Parameters() []float64
+}
+
+// Exponential distribution by rate.
+type Exp struct {
+	Rate float64
+}
+
+// Normal distribution by mean and standard deviation.
+type Normal struct {
+	Mu, Sigma float64
+}
+
+// Log-normal distribution by mean and standard deviation of logarithm.
+type LogNormal struct {
+	Mu, Sigma float64
+}
+
+// Distribution scaled by constant factor.
+type Scaled struct {
+	Distribution Distribution
+	Factor       float64
+}
+
+// Distribution shifted by constant offset.
+type Shifted struct {
+	Distribution Distribution
+	Offset       float64
+}
+
+// Triangular distribution by limits and mode.
+type Triangular struct {
+	Left, Mode, Right float64
+}
+
+// NewDistribution returns distribution by name and parameters.
+// Supported names are "uniform", "exponential", "normal", "lognormal" and "triangular".
+func NewDistribution(name string, parameters []float64) (Distribution, error) {
+	count := map[string]int{"uniform": 2, "exponential": 1, "normal": 2, "lognormal": 2, "triangular": 3}
+	if n, ok := count[name]; !ok {
+		return nil, errors.New(fmt.Sprintf("unknown distribution in NewDistribution: %q", name))
+	} else if n != len(parameters) {
+		return nil, errors.New(fmt.Sprintf("incorrect number of parameters for %s distribution: %d", name, len(parameters)))
+	}
+	p := parameters
+	switch name {
+	case "uniform":
+		if p[0] > p[1] {
+			break
+		}
+		return Pair{p[0], p[1]}, nil
+	case "exponential":
+		if p[0] <= 0 {
+			break
+		}
+		return Exp{1 / p[0]}, nil
+	case "normal":
+		if p[1] <= 0 {
+			break
+		}
+		return Normal{p[0], p[1]}, nil
+	case "lognormal":
+		if p[1] <= 0 {
+			break
+		}
+		return LogNormal{p[0], p[1]}, nil
+	case "triangular":
+		if !(p[0] <= p[1] && p[1] <= p[2] && p[0] < p[2]) {
+			break
+		}
+		return Triangular{p[0], p[1], p[2]}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("incorrect parameters for %s distribution: %v", name, parameters))
+}
+
+// Sample returns uniformly distributed random number.
+func (p Pair) Sample(r Source) (float64, error) {
+	return Uniform(r, p)
+}
+
+// Mean returns expected value.
+func (p Pair) Mean() float64 {
+	return (p.Left + p.Right) / 2
+}
+
+// CDF returns value of cumulative distribution function.
+func (p Pair) CDF(x float64) float64 {
+	switch {
+	case x <= p.Left:
+		return 0.0
+	case x >= p.Right:
+		return 1.0
+	}
+	return (x - p.Left) / (p.Right - p.Left)
+}
+
+// Name returns name of distribution.
+func (p Pair) Name() string {
+	return "uniform"
+}
+
+// Parameters returns limits.
+func (p Pair) Parameters() []float64 {
+	return []float64{p.Left, p.Right}
+}
+
+// Sample returns exponentially distributed random number.
+func (e Exp) Sample(r Source) (float64, error) {
+	return Exponential(r, e.Mean())
+}
+
+// Mean returns expected value.
+func (e Exp) Mean() float64 {
+	return 1 / e.Rate
+}
+
+// CDF returns value of cumulative distribution function.
+func (e Exp) CDF(x float64) float64 {
+	if x <= 0 {
+		return 0.0
+	}
+	return 1 - math.Exp(-e.Rate*x)
+}
+
+// Name returns name of distribution.
+func (e Exp) Name() string {
+	return "exponential"
+}
+
+// Parameters returns mean.
+func (e Exp) Parameters() []float64 {
+	return []float64{e.Mean()}
+}
+
+// Sample returns normally distributed random number. Negative numbers are truncated to zero.
+func (n Normal) Sample(r Source) (float64, error) {
+	z, err := statistic.NormalQuantile(open(r.Float64()))
+	if err != nil {
+		return 0.0, err
+	}
+	return math.Max(0, n.Mu+n.Sigma*z), nil
+}
+
+// Mean returns expected value.
+func (n Normal) Mean() float64 {
+	return n.Mu
+}
+
+// CDF returns value of cumulative distribution function.
+func (n Normal) CDF(x float64) float64 {
+	return 0.5 * math.Erfc(-(x-n.Mu)/(n.Sigma*math.Sqrt2))
+}
+
+// Name returns name of distribution.
+func (n Normal) Name() string {
+	return "normal"
+}
+
+// Parameters returns mean and standard deviation.
+func (n Normal) Parameters() []float64 {
+	return []float64{n.Mu, n.Sigma}
+}
+
+// Sample returns log-normally distributed random number.
+func (l LogNormal) Sample(r Source) (float64, error) {
+	z, err := statistic.NormalQuantile(open(r.Float64()))
+	if err != nil {
+		return 0.0, err
+	}
+	return math.Exp(l.Mu + l.Sigma*z), nil
+}
+
+// Mean returns expected value.
+func (l LogNormal) Mean() float64 {
+	return math.Exp(l.Mu + l.Sigma*l.Sigma/2)
+}
+
+// CDF returns value of cumulative distribution function.
+func (l LogNormal) CDF(x float64) float64 {
+	if x <= 0 {
+		return 0.0
+	}
+	return Normal{l.Mu, l.Sigma}.CDF(math.Log(x))
+}
+
+// Name returns name of distribution.
+func (l LogNormal) Name() string {
+	return "lognormal"
+}
+
+// Parameters returns mean and standard deviation of logarithm.
+func (l LogNormal) Parameters() []float64 {
+	return []float64{l.Mu, l.Sigma}
+}
+
+// Sample returns random number with triangular distribution.
+func (t Triangular) Sample(r Source) (float64, error) {
+	u, width := r.Float64(), t.Right-t.Left
+	if u < (t.Mode-t.Left)/width {
+		return t.Left + math.Sqrt(u*width*(t.Mode-t.Left)), nil
+	}
+	return t.Right - math.Sqrt((1-u)*width*(t.Right-t.Mode)), nil
+}
+
+// Mean returns expected value.
+func (t Triangular) Mean() float64 {
+	return (t.Left + t.Mode + t.Right) / 3
+}
+
+// CDF returns value of cumulative distribution function.
+func (t Triangular) CDF(x float64) float64 {
+	width := t.Right - t.Left
+	switch {
+	case x <= t.Left:
+		return 0.0
+	case x >= t.Right:
+		return 1.0
+	case x <= t.Mode:
+		return (x - t.Left) * (x - t.Left) / (width * (t.Mode - t.Left))
+	}
+	return 1 - (t.Right-x)*(t.Right-x)/(width*(t.Right-t.Mode))
+}
+
+// Name returns name of distribution.
+func (t Triangular) Name() string {
+	return "triangular"
+}
+
+// Parameters returns left limit, mode and right limit.
+func (t Triangular) Parameters() []float64 {
+	return []float64{t.Left, t.Mode, t.Right}
+}
+
+// Sample returns scaled random number of distribution.
+func (s Scaled) Sample(r Source) (float64, error) {
+	x, err := s.Distribution.Sample(r)
+	return x * s.Factor, err
+}
+
+// Mean returns expected value.
+func (s Scaled) Mean() float64 {
+	return s.Distribution.Mean() * s.Factor
+}
+
+// CDF returns value of cumulative distribution function.
+func (s Scaled) CDF(x float64) float64 {
+	return s.Distribution.CDF(x / s.Factor)
+}
+
+// Name returns name of scaled distribution.
+func (s Scaled) Name() string {
+	return s.Distribution.Name()
+}
+
+// Parameters returns parameters of scaled distribution, factor isn't included.
+func (s Scaled) Parameters() []float64 {
+	return s.Distribution.Parameters()
+}
+
+// Sample returns shifted random number of distribution.
+func (s Shifted) Sample(r Source) (float64, error) {
+	x, err := s.Distribution.Sample(r)
+	return x + s.Offset, err
+}
+
+// Mean returns expected value.
+func (s Shifted) Mean() float64 {
+	return s.Distribution.Mean() + s.Offset
+}
+
+// CDF returns value of cumulative distribution function.
+func (s Shifted) CDF(x float64) float64 {
+	return s.Distribution.CDF(x - s.Offset)
+}
+
+// Name returns name of shifted distribution.
+func (s Shifted) Name() string {
+	return s.Distribution.Name()
+}
+
+// Parameters returns parameters of shifted distribution, offset isn't included.
+func (s Shifted) Parameters() []float64 {
+	return s.Distribution.Parameters()
+}
+
+// open moves number from [0, 1] into open interval (0, 1), complementary number of antithetic source can be 1.
+func open(u float64) float64 {
+	if u <= 0 {
+		return math.SmallestNonzeroFloat64
+	}
+	if u >= 1 {
+		return math.Nextafter(1, 0)
+	}
+	return u
+}
@@ -0,0 +1,68 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewDistribution(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters []float64
+		mean       float64
+	}{
+		{"uniform", []float64{35, 55}, 45},
+		{"exponential", []float64{45}, 45},
+		{"normal", []float64{15, 1}, 15},
+		{"triangular", []float64{12, 15, 18}, 15},
+	}
+
+	for _, test := range tests {
+		d, err := NewDistribution(test.name, test.parameters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Mean() != test.mean {
+			t.Errorf("Expected mean %.2f of %s distribution, got %.2f", test.mean, test.name, d.Mean())
+		}
+		if m := d.CDF(test.mean); math.Abs(m-0.5) > 0.4 {
+			t.Errorf("Expected CDF near median of %s distribution, got %.2f", test.name, m)
+		}
+	}
+	if _, err := NewDistribution("uniform", []float64{55, 35}); err == nil {
+		t.Errorf("Expected error for incorrect limits")
+	}
+	if _, err := NewDistribution("gamma", []float64{1, 1}); err == nil {
+		t.Errorf("Expected error for unknown distribution")
+	}
+}
+
+func TestInverseTransform(t *testing.T) {
+	distributions := []Distribution{Pair{12, 18}, Exp{0.5}, Normal{15, 2}, LogNormal{1, 0.5}, Triangular{12, 13, 18}, Scaled{Pair{12, 18}, 0.25}, Shifted{Exp{0.5}, 10}}
+
+	for _, d := range distributions {
+		for _, u := range []float64{0.1, 0.5, 0.9} {
+			x, err := d.Sample(constant(u))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f := d.CDF(x); math.Abs(f-u) > 1e-6 {
+				t.Errorf("Expected CDF %.2f of sample of %s distribution, got %.6f", u, d.Name(), f)
+			}
+		}
+	}
+}
+
+func TestSampleBounds(t *testing.T) {
+	distributions := []Distribution{Pair{12, 18}, Exp{0.5}, Normal{15, 2}, LogNormal{1, 0.5}, Triangular{12, 13, 18}}
+
+	// Antithetic source returns 1 for 0 of wrapped source.
+	for _, d := range distributions {
+		for _, source := range []Source{constant(0), Antithetic{constant(0)}} {
+			x, err := d.Sample(source)
+			if err != nil || math.IsInf(x, 0) || math.IsNaN(x) {
+				t.Errorf("Expected finite sample of %s distribution by %.0f, got %f (%v)", d.Name(), source.Float64(), x, err)
+			}
+		}
+	}
+}
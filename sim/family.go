@@ -0,0 +1,149 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"simulation-modeling/statistic"
+	"sort"
+)
+
+// Family of transaction split into children. Family ends when its last member is disposed.
+type family struct {
+	created        float64
+	members, alive int
+	ended          float64
+	lifetimes      statistic.Unit
+}
+
+// Member of family held at assembly until other members arrive.
+type assembly struct {
+	tr      *Transaction
+	arrived int
+}
+
+// Statistic of family: number of created and alive members, time of creation of root transaction,
+// lifetime of family until its end or current time and mean lifetime of disposed members.
+type FamilyStatistic struct {
+	Family         int
+	Members, Alive int
+	Created        float64
+	Lifetime       float64
+	MemberLifetime float64
+}
+
+// family returns family of transaction, it's created at first split.
+func (s *Sim) family(tr *Transaction) *family {
+	f, ok := s.families[GetFamily(*tr)]
+	if !ok {
+		tr.family = tr.id
+		f = &family{created: tr.time - tr.lifetime, members: 1, alive: 1}
+		s.families[tr.id] = f
+	}
+	return f
+}
+
+// Split creates children of transaction in its family with the same priority, length and time of creation.
+// Children are placed to future event chain after specified time by target waypoint.
+func (s *Sim) Split(tr *Transaction, count int, nextTime float64, targetPoint int) ([]*Transaction, error) {
+	if count < 1 {
+		return nil, errors.New(fmt.Sprintf("incorrect number of children in Sim.Split: %d", count))
+	}
+	f := s.family(tr)
+	children := make([]*Transaction, 0, count)
+	for i := 0; i < count; i++ {
+		s.idCounter++
+		child := &Transaction{s.idCounter, 0, targetPoint, s.simTime + nextTime, tr.lifetime + s.simTime - tr.time + nextTime,
+			tr.priority, tr.length, tr.family}
+		f.members++
+		f.alive++
+		for _, o := range s.observers {
+			o.Generate(s.simTime, child)
+		}
+		if err := s.fec.Insert(child); err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	return children, nil
+}
+
+// Dispose removes member of family from simulation, its lifetime is added to statistic of family.
+// Transaction which isn't in family is ignored.
+func (s *Sim) Dispose(tr *Transaction) {
+	f, ok := s.families[GetFamily(*tr)]
+	if !ok {
+		return
+	}
+	for _, o := range s.observers {
+		o.Dispose(s.simTime, tr)
+	}
+	f.lifetimes.AddValue(tr.lifetime + s.simTime - tr.time)
+	if f.alive--; f.alive == 0 {
+		f.ended = s.simTime
+	}
+}
+
+// Assemble gathers members of family at assembly by name. The first arriving member is held and
+// following members are disposed until count of members arrive, then held member returns to future event chain
+// at current time as combined transaction. It returns combined transaction when assembly is complete and
+// true if arrived transaction is disposed.
+func (s *Sim) Assemble(tr *Transaction, name string, count int) (*Transaction, bool, error) {
+	if count < 1 {
+		return nil, false, errors.New(fmt.Sprintf("incorrect number of members in Sim.Assemble: %d", count))
+	}
+	assemblies, ok := s.assemblies[name]
+	if !ok {
+		assemblies = make(map[int]*assembly)
+		s.assemblies[name] = assemblies
+	}
+	for _, o := range s.observers {
+		o.Assemble(s.simTime, tr, name)
+	}
+	a, ok := assemblies[GetFamily(*tr)]
+	disposed := ok
+	if ok {
+		a.arrived++
+		s.Dispose(tr)
+	} else {
+		a = &assembly{tr, 1}
+		assemblies[GetFamily(*tr)] = a
+	}
+	if a.arrived < count {
+		return nil, disposed, nil
+	}
+	delete(assemblies, GetFamily(*tr))
+	return a.tr, disposed, s.Delay(a.tr, s.simTime-a.tr.time)
+}
+
+// GetAssembling returns transactions held at assembly sorted by id.
+func (s *Sim) GetAssembling(name string) []*Transaction {
+	var result []*Transaction
+	for _, a := range s.assemblies[name] {
+		result = append(result, a.tr)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result
+}
+
+// GetFamilies returns ids of split families sorted in ascending order.
+func (s *Sim) GetFamilies() []int {
+	result := make([]int, 0, len(s.families))
+	for id := range s.families {
+		result = append(result, id)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// GetFamilyStatistic returns statistic of family by id.
+func (s *Sim) GetFamilyStatistic(id int) (FamilyStatistic, error) {
+	f, ok := s.families[id]
+	if !ok {
+		return FamilyStatistic{}, errors.New(fmt.Sprintf("no family %d in Sim.GetFamilyStatistic", id))
+	}
+	statistic := FamilyStatistic{id, f.members, f.alive, f.created, s.simTime - f.created, f.lifetimes.Mean()}
+	if f.alive == 0 {
+		statistic.Lifetime = f.ended - f.created
+	}
+	return statistic, nil
+}
@@ -0,0 +1,68 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFamily(t *testing.T) {
+	s := New(2)
+	s.Init()
+	s.Generate(1, 1)
+	cec, _ := s.Extraction()
+	root := cec[0]
+	children, err := s.Split(root, 2, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 2 || GetFamily(*children[0]) != 1 || GetFamily(*children[1]) != 1 || GetTime(*children[1]) != 1 {
+		t.Errorf("Expected two children of family 1 at 1, got %v", children)
+	}
+	s.Extraction()
+
+	// The first child is held, root and the second child are disposed when they arrive.
+	s.CorrectTime(4)
+	if combined, disposed, err := s.Assemble(children[0], "J", 3); combined != nil || disposed || err != nil {
+		t.Errorf("Expected held transaction, got %v, %t, %v", combined, disposed, err)
+	}
+	s.CorrectTime(6)
+	if combined, disposed, _ := s.Assemble(root, "J", 3); combined != nil || !disposed {
+		t.Errorf("Expected disposed transaction, got %v, %t", combined, disposed)
+	}
+	restored, err := Restore(s.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DebugString() != s.DebugString() {
+		t.Errorf("Expected state %s, got %s", s.DebugString(), restored.DebugString())
+	}
+	if held := ids(restored.GetAssembling("J")); !reflect.DeepEqual(held, []int{2}) {
+		t.Errorf("Expected transaction 2 held in restored simulator, got %v", held)
+	}
+	s.CorrectTime(9)
+	combined, disposed, err := s.Assemble(children[1], "J", 3)
+	if combined != children[0] || !disposed || err != nil {
+		t.Errorf("Expected combined transaction 2, got %v, %t, %v", combined, disposed, err)
+	}
+	if tr, ok := s.Pending(2); !ok || GetTime(*tr) != 9 {
+		t.Errorf("Expected combined transaction in future event chain at 9, got %v", tr)
+	}
+	expected := FamilyStatistic{1, 3, 1, 1, 8, 6.5}
+	if statistic, _ := s.GetFamilyStatistic(1); statistic != expected {
+		t.Errorf("Expected %+v, got %+v", expected, statistic)
+	}
+
+	s.Extraction()
+	s.CorrectTime(12)
+	s.Dispose(combined)
+	expected = FamilyStatistic{1, 3, 0, 1, 11, 8}
+	if statistic, _ := s.GetFamilyStatistic(1); statistic != expected {
+		t.Errorf("Expected %+v, got %+v", expected, statistic)
+	}
+	if families := s.GetFamilies(); !reflect.DeepEqual(families, []int{1}) {
+		t.Errorf("Expected family 1, got %v", families)
+	}
+	if _, err := s.GetFamilyStatistic(2); err == nil {
+		t.Errorf("Expected error for unknown family")
+	}
+}
@@ -0,0 +1,79 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Transaction taken out of future event chain before its event, remaining time is time left until the event.
+type interruption struct {
+	tr        *Transaction
+	remaining float64
+}
+
+// Pending returns transaction of future event chain by id.
+func (s *Sim) Pending(id int) (*Transaction, bool) {
+	return s.fec.Find(id)
+}
+
+// Cancel removes transaction from future event chain by id and returns it, its event doesn't happen.
+func (s *Sim) Cancel(id int) (*Transaction, error) {
+	return s.fec.Cancel(id)
+}
+
+// Reschedule moves event of transaction to new time, it must not be earlier than current time.
+func (s *Sim) Reschedule(id int, newTime float64) error {
+	if newTime < s.simTime {
+		return errors.New(fmt.Sprintf("time %f is earlier than current time in Sim.Reschedule", newTime))
+	}
+	return s.fec.Reschedule(id, newTime)
+}
+
+// Interrupt takes transaction out of future event chain and records time left until its event.
+// It returns remaining time, transaction continues by Sim.ResumeInterrupted.
+func (s *Sim) Interrupt(id int) (float64, error) {
+	tr, err := s.fec.Cancel(id)
+	if err != nil {
+		return 0.0, err
+	}
+	remaining := tr.time - s.simTime
+	tr.Wait(-remaining)
+	s.interrupted[id] = &interruption{tr, remaining}
+	for _, o := range s.observers {
+		o.Interrupt(s.simTime, tr, remaining)
+	}
+	return remaining, nil
+}
+
+// ResumeInterrupted returns interrupted transaction to future event chain, its event happens after remaining time.
+// Time of interruption counts in lifetime of transaction.
+func (s *Sim) ResumeInterrupted(id int) error {
+	i, ok := s.interrupted[id]
+	if !ok {
+		return errors.New(fmt.Sprintf("transaction %d isn't interrupted in Sim.ResumeInterrupted", id))
+	}
+	delete(s.interrupted, id)
+	for _, o := range s.observers {
+		o.ResumeInterrupted(s.simTime, i.tr)
+	}
+	return s.Delay(i.tr, s.simTime-i.tr.time+i.remaining)
+}
+
+// GetRemaining returns remaining time of interrupted transaction.
+func (s *Sim) GetRemaining(id int) (float64, bool) {
+	if i, ok := s.interrupted[id]; ok {
+		return i.remaining, true
+	}
+	return 0.0, false
+}
+
+// GetInterrupted returns interrupted transactions sorted by id.
+func (s *Sim) GetInterrupted() []*Transaction {
+	result := make([]*Transaction, 0, len(s.interrupted))
+	for _, i := range s.interrupted {
+		result = append(result, i.tr)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result
+}
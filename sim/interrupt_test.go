@@ -0,0 +1,63 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterrupt(t *testing.T) {
+	s := New(2)
+	s.Init()
+	for _, time := range []float64{5, 10, 10, 20} {
+		s.Generate(time, 1)
+	}
+	if tr, ok := s.Pending(3); !ok || GetTime(*tr) != 10 {
+		t.Errorf("Expected pending transaction 3 at 10, got %v", tr)
+	}
+	if _, err := s.Cancel(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Pending(1); ok {
+		t.Errorf("Expected cancelled transaction isn't pending")
+	}
+	if err := s.Reschedule(4, 10); err != nil {
+		t.Fatal(err)
+	}
+	if future := ids(s.GetFuture()); !reflect.DeepEqual(future, []int{4, 3, 2}) {
+		t.Errorf("Expected future [4 3 2], got %v", future)
+	}
+
+	s.CorrectTime(4)
+	remaining, err := s.Interrupt(3)
+	if err != nil || remaining != 6 {
+		t.Fatalf("Expected remaining time 6, got %f, %v", remaining, err)
+	}
+	if _, ok := s.Pending(3); ok || len(s.GetInterrupted()) != 1 {
+		t.Errorf("Expected transaction 3 out of future event chain")
+	}
+	restored, err := Restore(s.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DebugString() != s.DebugString() {
+		t.Errorf("Expected state %s, got %s", s.DebugString(), restored.DebugString())
+	}
+
+	// Transaction interrupted at 4 for 3 minutes reaches its event at 13.
+	s.CorrectTime(7)
+	if err := s.ResumeInterrupted(3); err != nil {
+		t.Fatal(err)
+	}
+	if tr, ok := s.Pending(3); !ok || GetTime(*tr) != 13 {
+		t.Errorf("Expected transaction 3 at 13, got %v", tr)
+	}
+	if err := s.ResumeInterrupted(3); err == nil {
+		t.Errorf("Expected error of resumption of transaction which isn't interrupted")
+	}
+	if err := s.Reschedule(2, 1); err == nil {
+		t.Errorf("Expected error of rescheduling to past")
+	}
+	if _, err := s.Cancel(7); err == nil {
+		t.Errorf("Expected error of cancellation of unknown transaction")
+	}
+}
@@ -0,0 +1,154 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// List of supported orders of unlinking transactions from chains.
+const (
+	FIFO = iota
+	LIFO
+	// Transactions with higher priority first, FIFO for equal priority.
+	ByPriority
+	// Longer transactions first, FIFO for equal length.
+	ByLength
+)
+
+// Chain of blocked transactions. Transactions in chain aren't in future event chain and waitlist,
+// they return to future event chain when they are unlinked.
+type userChain struct {
+	order        int
+	transactions []*Transaction
+}
+
+// chain returns user chain by name, it's created at first reference.
+func (s *Sim) chain(name string) *userChain {
+	ch, ok := s.userChains[name]
+	if !ok {
+		ch = &userChain{}
+		s.userChains[name] = ch
+	}
+	return ch
+}
+
+// pointChain returns chain of transactions waiting for release of point.
+func (s *Sim) pointChain(p int) *userChain {
+	ch, ok := s.pointChains[p]
+	if !ok {
+		ch = &userChain{}
+		s.pointChains[p] = ch
+	}
+	return ch
+}
+
+// sorted returns transactions of chain in unlink order.
+func (ch *userChain) sorted() []*Transaction {
+	result := append([]*Transaction(nil), ch.transactions...)
+	switch ch.order {
+	case LIFO:
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	case ByPriority:
+		sort.SliceStable(result, func(i, j int) bool { return result[i].priority > result[j].priority })
+	case ByLength:
+		sort.SliceStable(result, func(i, j int) bool { return result[i].length > result[j].length })
+	}
+	return result
+}
+
+// unlink removes first count transactions of chain in unlink order, all of them if count isn't positive,
+// and returns them to future event chain at current time in the same order.
+func (s *Sim) unlink(ch *userChain, count int) ([]*Transaction, error) {
+	unlinked := ch.sorted()
+	if count > 0 && count < len(unlinked) {
+		unlinked = unlinked[:count]
+	}
+	removed := make(map[*Transaction]bool, len(unlinked))
+	for _, tr := range unlinked {
+		removed[tr] = true
+	}
+	rest := ch.transactions[:0]
+	for _, tr := range ch.transactions {
+		if !removed[tr] {
+			rest = append(rest, tr)
+		}
+	}
+	ch.transactions = rest
+	// Transaction inserted later is placed before transactions with the same time.
+	for i := len(unlinked) - 1; i >= 0; i-- {
+		if err := s.Delay(unlinked[i], s.simTime-unlinked[i].time); err != nil {
+			return nil, err
+		}
+	}
+	return unlinked, nil
+}
+
+func checkOrder(order int) error {
+	if order < FIFO || order > ByLength {
+		return errors.New(fmt.Sprintf("incorrect order of chain: %d", order))
+	}
+	return nil
+}
+
+// SetChainOrder sets unlink order of user chain, FIFO is default.
+func (s *Sim) SetChainOrder(name string, order int) error {
+	if err := checkOrder(order); err != nil {
+		return err
+	}
+	s.chain(name).order = order
+	return nil
+}
+
+// Link adds transaction to user chain, transaction must be out of future event chain and waitlist.
+func (s *Sim) Link(tr *Transaction, name string) {
+	ch := s.chain(name)
+	ch.transactions = append(ch.transactions, tr)
+}
+
+// Unlink returns first count transactions of user chain in its order to future event chain at current time,
+// all of them if count isn't positive. It returns unlinked transactions.
+func (s *Sim) Unlink(name string, count int) ([]*Transaction, error) {
+	return s.unlink(s.chain(name), count)
+}
+
+// GetChain returns transactions of user chain in unlink order.
+func (s *Sim) GetChain(name string) []*Transaction {
+	if ch, ok := s.userChains[name]; ok {
+		return ch.sorted()
+	}
+	return nil
+}
+
+// SetPointOrder sets order of transactions waiting for release of point, FIFO is default.
+func (s *Sim) SetPointOrder(p, order int) error {
+	if p < 0 || p >= s.points {
+		return errors.New("incorrect point's id in Sim.SetPointOrder")
+	}
+	if err := checkOrder(order); err != nil {
+		return err
+	}
+	s.pointChain(p).order = order
+	return nil
+}
+
+// WaitFor links transaction to chain of point. When point is released all transactions waiting for it
+// return to future event chain at current time in order of chain, so only they try to seize it again.
+func (s *Sim) WaitFor(tr *Transaction, p int) error {
+	if p < 0 || p >= s.points {
+		return errors.New("incorrect point's id in Sim.WaitFor")
+	}
+	ch := s.pointChain(p)
+	ch.transactions = append(ch.transactions, tr)
+	return nil
+}
+
+// GetWaiting returns transactions waiting for release of point in order of chain.
+func (s *Sim) GetWaiting(p int) []*Transaction {
+	if ch, ok := s.pointChains[p]; ok {
+		return ch.sorted()
+	}
+	return nil
+}
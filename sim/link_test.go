@@ -0,0 +1,93 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func ids(chain []*Transaction) []int {
+	var result []int
+	for _, tr := range chain {
+		result = append(result, GetId(*tr))
+	}
+	return result
+}
+
+func TestLink(t *testing.T) {
+	s := New(2)
+	s.Init()
+	var trs []*Transaction
+	for i, priority := range []int{0, 2, 1, 2} {
+		tr := NewTransaction(i+1, 0, 1)
+		tr.SetPriority(priority)
+		trs = append(trs, tr)
+	}
+	cases := []struct {
+		order    int
+		expected []int
+	}{
+		{FIFO, []int{1, 2, 3, 4}},
+		{LIFO, []int{4, 3, 2, 1}},
+		{ByPriority, []int{2, 4, 3, 1}},
+	}
+	for _, c := range cases {
+		if err := s.SetChainOrder("C", c.order); err != nil {
+			t.Fatal(err)
+		}
+		for _, tr := range trs {
+			s.Link(tr, "C")
+		}
+		s.CorrectTime(5)
+		unlinked, err := s.Unlink("C", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids(unlinked), c.expected[:3]) {
+			t.Errorf("Expected unlinked %v in order %d, got %v", c.expected[:3], c.order, ids(unlinked))
+		}
+		if !reflect.DeepEqual(ids(s.GetFuture()), c.expected[:3]) || GetTime(*s.GetFuture()[0]) != 5 {
+			t.Errorf("Expected future %v at 5, got %s", c.expected[:3], s.fec)
+		}
+		if rest := ids(s.GetChain("C")); !reflect.DeepEqual(rest, c.expected[3:]) {
+			t.Errorf("Expected %v in chain, got %v", c.expected[3:], rest)
+		}
+		s.Unlink("C", 0)
+		s.Extraction()
+	}
+	if err := s.SetChainOrder("C", 7); err == nil {
+		t.Errorf("Expected error of incorrect order")
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	s := New(3)
+	s.Init()
+	s.SeizePoint(1)
+	s.SeizePoint(2)
+	a, b, c := NewTransaction(1, 0, 1), NewTransaction(2, 0, 1), NewTransaction(3, 0, 2)
+	s.SetPointOrder(1, LIFO)
+	s.WaitFor(a, 1)
+	s.WaitFor(b, 1)
+	s.WaitFor(c, 2)
+
+	restored, err := Restore(s.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DebugString() != s.DebugString() {
+		t.Errorf("Expected state %s, got %s", s.DebugString(), restored.DebugString())
+	}
+
+	// Release of point wakes only transactions waiting for it.
+	s.CorrectTime(4)
+	s.ReleasePoint(1)
+	if future := ids(s.GetFuture()); !reflect.DeepEqual(future, []int{2, 1}) {
+		t.Errorf("Expected future [2 1], got %v", future)
+	}
+	if waiting := ids(s.GetWaiting(2)); !reflect.DeepEqual(waiting, []int{3}) || len(s.GetWaiting(1)) != 0 {
+		t.Errorf("Expected only transaction 3 waiting, got %v and %v", ids(s.GetWaiting(1)), waiting)
+	}
+	if waiting := ids(restored.GetWaiting(1)); !reflect.DeepEqual(waiting, []int{2, 1}) {
+		t.Errorf("Expected [2 1] waiting in restored simulator, got %v", waiting)
+	}
+}
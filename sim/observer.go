@@ -0,0 +1,59 @@
+package sim
+
+// Observer receives events of simulator with simulation time of event.
+// Transaction of seize and release is nil if point is seized or released directly, not by Sim.UsePoint.
+type Observer interface {
+	// Generate is called for new transaction.
+	Generate(time float64, tr *Transaction)
+	// Seize is called when point is seized.
+	Seize(time float64, tr *Transaction, point int)
+	// Release is called when point is released.
+	Release(time float64, tr *Transaction, point int)
+	// Wait is called when transaction is added to waitlist.
+	Wait(time float64, tr *Transaction)
+	// Resume is called when transaction is removed from waitlist.
+	Resume(time float64, tr *Transaction)
+	// Interrupt is called when transaction is taken out of future event chain with remaining time of its event.
+	Interrupt(time float64, tr *Transaction, remaining float64)
+	// ResumeInterrupted is called when interrupted transaction returns to future event chain.
+	ResumeInterrupted(time float64, tr *Transaction)
+	// Assemble is called when member of family arrives at assembly by name.
+	Assemble(time float64, tr *Transaction, name string)
+	// Dispose is called when member of family is removed from simulation.
+	Dispose(time float64, tr *Transaction)
+	// Extract is called for current events chain extracted from future event chain.
+	Extract(time float64, cec []*Transaction)
+	// Terminate is called when simulation is completed.
+	Terminate(time float64)
+}
+
+// NopObserver ignores all events, it's embedded in observers which need only some of them.
+type NopObserver struct{}
+
+func (NopObserver) Generate(time float64, tr *Transaction)                     {}
+func (NopObserver) Seize(time float64, tr *Transaction, point int)             {}
+func (NopObserver) Release(time float64, tr *Transaction, point int)           {}
+func (NopObserver) Wait(time float64, tr *Transaction)                         {}
+func (NopObserver) Resume(time float64, tr *Transaction)                       {}
+func (NopObserver) Interrupt(time float64, tr *Transaction, remaining float64) {}
+func (NopObserver) ResumeInterrupted(time float64, tr *Transaction)            {}
+func (NopObserver) Assemble(time float64, tr *Transaction, name string)        {}
+func (NopObserver) Dispose(time float64, tr *Transaction)                      {}
+func (NopObserver) Extract(time float64, cec []*Transaction)                   {}
+func (NopObserver) Terminate(time float64)                                     {}
+
+// AddObserver registers observer of events, observers are called in order of registration.
+// Observers aren't kept in snapshot and clone of simulator.
+func (s *Sim) AddObserver(o Observer) {
+	s.observers = append(s.observers, o)
+}
+
+// RemoveObserver unregisters observer.
+func (s *Sim) RemoveObserver(o Observer) {
+	for i, observer := range s.observers {
+		if observer == o {
+			s.observers = append(s.observers[:i], s.observers[i+1:]...)
+			return
+		}
+	}
+}
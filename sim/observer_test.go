@@ -0,0 +1,124 @@
+package sim
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type recorder struct {
+	NopObserver
+	events []string
+}
+
+func (r *recorder) Generate(time float64, tr *Transaction) {
+	r.events = append(r.events, fmt.Sprintf("generate %d at %.0f", GetId(*tr), time))
+}
+
+func (r *recorder) Seize(time float64, tr *Transaction, point int) {
+	id := 0
+	if tr != nil {
+		id = GetId(*tr)
+	}
+	r.events = append(r.events, fmt.Sprintf("seize %d by %d at %.0f", point, id, time))
+}
+
+func (r *recorder) Release(time float64, tr *Transaction, point int) {
+	r.events = append(r.events, fmt.Sprintf("release %d at %.0f", point, time))
+}
+
+func (r *recorder) Wait(time float64, tr *Transaction) {
+	r.events = append(r.events, fmt.Sprintf("wait %d", GetId(*tr)))
+}
+
+func (r *recorder) Resume(time float64, tr *Transaction) {
+	r.events = append(r.events, fmt.Sprintf("resume %d", GetId(*tr)))
+}
+
+func (r *recorder) Extract(time float64, cec []*Transaction) {
+	r.events = append(r.events, fmt.Sprintf("extract %d at %.0f", len(cec), time))
+}
+
+func (r *recorder) Terminate(time float64) {
+	r.events = append(r.events, fmt.Sprintf("terminate at %.0f", time))
+}
+
+func TestObserver(t *testing.T) {
+	s := New(3)
+	s.Init()
+	r := &recorder{}
+	s.AddObserver(r)
+	s.AddObserver(NopObserver{})
+	s.Generate(5, 1)
+	cec, _ := s.Extraction()
+	s.UsePoint(cec[0], 2, 2)
+	s.AddToWaitlist(cec[0])
+	s.RemoveFromWaitlist(cec[0])
+	s.RemoveFromWaitlist(cec[0])
+	s.SeizePoint(0)
+	s.Terminate()
+	s.RemoveObserver(r)
+	s.Generate(1, 1)
+
+	expected := []string{
+		"generate 1 at 0",
+		"extract 1 at 5",
+		"release 0 at 5",
+		"seize 1 by 1 at 5",
+		"wait 1",
+		"resume 1",
+		"seize 0 by 0 at 5",
+		"terminate at 5",
+	}
+	if !reflect.DeepEqual(r.events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, r.events)
+	}
+}
+
+func (r *recorder) Interrupt(time float64, tr *Transaction, remaining float64) {
+	r.events = append(r.events, fmt.Sprintf("interrupt %d for %.0f", GetId(*tr), remaining))
+}
+
+func (r *recorder) ResumeInterrupted(time float64, tr *Transaction) {
+	r.events = append(r.events, fmt.Sprintf("resume interrupted %d at %.0f", GetId(*tr), time))
+}
+
+func (r *recorder) Assemble(time float64, tr *Transaction, name string) {
+	r.events = append(r.events, fmt.Sprintf("assemble %d at %s", GetId(*tr), name))
+}
+
+func (r *recorder) Dispose(time float64, tr *Transaction) {
+	r.events = append(r.events, fmt.Sprintf("dispose %d", GetId(*tr)))
+}
+
+func TestObserverInterruptAndFamily(t *testing.T) {
+	s := New(3)
+	s.Init()
+	r := &recorder{}
+	s.Generate(5, 1)
+	s.AddObserver(r)
+	s.Interrupt(1)
+	if _, err := s.Interrupt(100); err == nil {
+		t.Errorf("Expected error of interrupt of unknown transaction")
+	}
+	s.ResumeInterrupted(1)
+	cec, _ := s.Extraction()
+	children, _ := s.Split(cec[0], 1, 0, 2)
+	s.Assemble(cec[0], "a", 2)
+	s.Assemble(children[0], "a", 2)
+	s.Dispose(cec[0])
+
+	expected := []string{
+		"interrupt 1 for 5",
+		"resume interrupted 1 at 0",
+		"extract 1 at 5",
+		"generate 2 at 5",
+		"assemble 1 at a",
+		"assemble 2 at a",
+		"dispose 2",
+		"dispose 1",
+	}
+	if !reflect.DeepEqual(r.events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, r.events)
+	}
+}
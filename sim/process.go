@@ -0,0 +1,152 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// Process is sequential code of transaction. Processes run as goroutines, but only one of them runs at a time:
+// environment resumes process and waits until it holds, waits for condition or ends.
+type Process struct {
+	Tr        *Transaction
+	Env       *Env
+	body      func(p *Process)
+	resume    chan bool
+	started   bool
+	condition func() bool
+}
+
+// Env runs processes by future event chain and waitlist of simulator.
+type Env struct {
+	S *Sim
+	// Order returns waiting transactions in order of check of their conditions, waitlist order is used if it's nil.
+	Order     func(waiting []*Transaction) []*Transaction
+	processes map[int]*Process
+	yield     chan bool
+}
+
+// NewEnv returns environment of processes of simulator.
+func NewEnv(s *Sim) *Env {
+	return &Env{S: s, processes: make(map[int]*Process), yield: make(chan bool)}
+}
+
+// Spawn creates transaction by target waypoint and priority after specified time, its process runs body.
+func (e *Env) Spawn(nextTime float64, targetPoint, priority int, body func(p *Process)) (*Process, error) {
+	tr, err := e.S.GeneratePriority(nextTime, targetPoint, priority)
+	if err != nil {
+		return nil, err
+	}
+	p := &Process{Tr: tr, Env: e, body: body, resume: make(chan bool)}
+	e.processes[GetId(*tr)] = p
+	return p, nil
+}
+
+// run resumes process and waits until it blocks or ends.
+func (e *Env) run(p *Process) {
+	if !p.started {
+		p.started = true
+		go func() {
+			defer func() {
+				delete(e.processes, GetId(*p.Tr))
+				e.yield <- true
+			}()
+			if <-p.resume {
+				p.body(p)
+			}
+		}()
+	}
+	p.resume <- true
+	<-e.yield
+}
+
+// block returns control to environment until process is resumed.
+func (p *Process) block() {
+	p.Env.yield <- true
+	if !<-p.resume {
+		runtime.Goexit()
+	}
+}
+
+// Step runs one phase of simulation: processes of current events chain are resumed in order of chain,
+// then waiting processes are resumed if their conditions are true.
+func (e *Env) Step() error {
+	cec, err := e.S.Extraction()
+	if err != nil {
+		return err
+	}
+	for _, tr := range cec {
+		if p, ok := e.processes[GetId(*tr)]; ok {
+			e.run(p)
+		}
+	}
+	waiting := append([]*Transaction(nil), e.S.GetWaitlist()...)
+	if e.Order != nil {
+		waiting = e.Order(waiting)
+	}
+	for _, tr := range waiting {
+		p, ok := e.processes[GetId(*tr)]
+		if !ok || !p.condition() {
+			continue
+		}
+		e.S.RemoveFromWaitlist(tr)
+		e.run(p)
+	}
+	return nil
+}
+
+// Close stops goroutines of all processes which haven't ended.
+func (e *Env) Close() {
+	for id, p := range e.processes {
+		if p.started {
+			p.resume <- false
+			<-e.yield
+		}
+		delete(e.processes, id)
+	}
+}
+
+// Hold returns transaction to future event chain for specified time after current time.
+func (p *Process) Hold(time float64) error {
+	s := p.Env.S
+	if err := s.Delay(p.Tr, s.simTime-p.Tr.time+time); err != nil {
+		return err
+	}
+	p.block()
+	return nil
+}
+
+// WaitUntil adds transaction to waitlist until condition is true, it returns waiting time.
+// Condition is checked at once and then after each phase of simulation.
+func (p *Process) WaitUntil(condition func() bool) float64 {
+	if condition() {
+		return 0
+	}
+	p.condition = condition
+	p.Env.S.AddToWaitlist(p.Tr)
+	p.block()
+	return p.Env.S.simTime - p.Tr.time
+}
+
+// Seize seizes point, it becomes current waypoint of transaction.
+func (p *Process) Seize(point int) error {
+	if err := p.Env.S.seize(p.Tr, point); err != nil {
+		return err
+	}
+	p.Tr.currentPoint = point
+	return nil
+}
+
+// Release releases point.
+func (p *Process) Release(point int) error {
+	return p.Env.S.release(p.Tr, point)
+}
+
+// Head sets next waypoint of transaction.
+func (p *Process) Head(point int) error {
+	if point < 0 || point >= p.Env.S.points {
+		return errors.New(fmt.Sprintf("incorrect point's id in Process.Head: %d", point))
+	}
+	p.Tr.nextPoint = point
+	return nil
+}
@@ -0,0 +1,50 @@
+package sim
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProcess(t *testing.T) {
+	s := New(2)
+	s.Init()
+	e := NewEnv(s)
+	var log []string
+	// Two processes use point 1 for 3 minutes one after another, the third one waits forever.
+	body := func(p *Process) {
+		waiting := p.WaitUntil(func() bool { free, _ := s.Test([]int{1}); return free })
+		p.Seize(1)
+		p.Head(0)
+		log = append(log, fmt.Sprintf("%d seized at %.0f after %.0f", GetId(*p.Tr), s.GetSimTime(), waiting))
+		p.Hold(3)
+		p.Release(1)
+		log = append(log, fmt.Sprintf("%d released at %.0f", GetId(*p.Tr), s.GetSimTime()))
+	}
+	e.Spawn(1, 1, 0, body)
+	e.Spawn(2, 1, 0, body)
+	e.Spawn(20, 1, 0, func(p *Process) { p.Seize(1); p.WaitUntil(func() bool { return false }) })
+	e.Spawn(30, 1, 0, func(p *Process) { s.Terminate() })
+	for !s.IsFinish() {
+		if err := e.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.Close()
+
+	expected := []string{
+		"1 seized at 1 after 0",
+		"1 released at 4",
+		"2 seized at 4 after 2",
+		"2 released at 7",
+	}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected %v, got %v", expected, log)
+	}
+	if len(e.processes) != 0 {
+		t.Errorf("Expected no processes after close, got %d", len(e.processes))
+	}
+	if state, _ := s.GetPointState(1); state != Used {
+		t.Errorf("Expected state %d, got %d", Used, state)
+	}
+}
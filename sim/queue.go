@@ -0,0 +1,116 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Named queue which transactions join and depart explicitly. Content is number of units in queue,
+// integral of content by time is kept for average content and average time of entry.
+type queue struct {
+	content, max, entries, zeroEntries int
+	integral, changed                  float64
+	// Time of entry and units of transactions in queue by their id.
+	entered map[int]QueueEntry
+}
+
+// Entry of transaction in queue.
+type QueueEntry struct {
+	Time  float64
+	Units int
+}
+
+// Statistic of queue: current, maximal and average content, total entries, entries with zero time in queue,
+// average time per entry and average time per entry excluding zero entries.
+type QueueStatistic struct {
+	Name                               string
+	Content, Max, Entries, ZeroEntries int
+	AverageContent, AverageTime        float64
+	AverageNonZero                     float64
+}
+
+// queue returns queue by name, it's created at first reference.
+func (s *Sim) queue(name string) *queue {
+	q, ok := s.queues[name]
+	if !ok {
+		q = &queue{changed: s.simTime, entered: make(map[int]QueueEntry)}
+		s.queues[name] = q
+		s.queueNames = append(s.queueNames, name)
+	}
+	return q
+}
+
+// integrate adds integral of content until current time.
+func (q *queue) integrate(now float64) {
+	q.integral += float64(q.content) * (now - q.changed)
+	q.changed = now
+}
+
+// Join adds transaction to queue by specified number of units.
+func (s *Sim) Join(tr *Transaction, name string, units int) error {
+	q := s.queue(name)
+	if units < 1 {
+		return errors.New(fmt.Sprintf("incorrect number of units in Sim.Join: %d", units))
+	}
+	if _, ok := q.entered[tr.id]; ok {
+		return errors.New(fmt.Sprintf("transaction %d is already in queue %s in Sim.Join", tr.id, name))
+	}
+	q.integrate(s.simTime)
+	q.content += units
+	q.entries += units
+	if q.content > q.max {
+		q.max = q.content
+	}
+	q.entered[tr.id] = QueueEntry{s.simTime, units}
+	return nil
+}
+
+// Depart removes transaction from queue and returns its time in queue.
+func (s *Sim) Depart(tr *Transaction, name string) (float64, error) {
+	q := s.queue(name)
+	entry, ok := q.entered[tr.id]
+	if !ok {
+		return 0.0, errors.New(fmt.Sprintf("transaction %d isn't in queue %s in Sim.Depart", tr.id, name))
+	}
+	q.integrate(s.simTime)
+	q.content -= entry.Units
+	if s.simTime == entry.Time {
+		q.zeroEntries += entry.Units
+	}
+	delete(q.entered, tr.id)
+	return s.simTime - entry.Time, nil
+}
+
+// InQueue returns true if transaction is in queue.
+func (s *Sim) InQueue(tr *Transaction, name string) bool {
+	if q, ok := s.queues[name]; ok {
+		_, ok = q.entered[tr.id]
+		return ok
+	}
+	return false
+}
+
+// GetQueueStatistic returns statistic of queue at current time.
+func (s *Sim) GetQueueStatistic(name string) QueueStatistic {
+	q, ok := s.queues[name]
+	if !ok {
+		return QueueStatistic{Name: name}
+	}
+	integral := q.integral + float64(q.content)*(s.simTime-q.changed)
+	statistic := QueueStatistic{Name: name, Content: q.content, Max: q.max, Entries: q.entries, ZeroEntries: q.zeroEntries}
+	if s.simTime > 0 {
+		statistic.AverageContent = integral / s.simTime
+	}
+	if q.entries > 0 {
+		statistic.AverageTime = integral / float64(q.entries)
+	}
+	if q.entries > q.zeroEntries {
+		statistic.AverageNonZero = integral / float64(q.entries-q.zeroEntries)
+	}
+	return statistic
+}
+
+// GetQueues returns names of queues in order of their creation.
+func (s *Sim) GetQueues() []string {
+	return append([]string(nil), s.queueNames...)
+}
@@ -0,0 +1,48 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	s := New(1)
+	s.Init()
+	a, b, c := NewTransaction(1, 0, 0), NewTransaction(2, 0, 0), NewTransaction(3, 0, 0)
+	// A waits from 1 to 5, B passes at 2 without waiting, C waits from 3 and is in queue at 10.
+	s.CorrectTime(1)
+	s.Join(a, "Q", 1)
+	s.CorrectTime(2)
+	s.Join(b, "Q", 1)
+	if time, err := s.Depart(b, "Q"); err != nil || time != 0 {
+		t.Errorf("Expected zero time in queue, got %f, %v", time, err)
+	}
+	s.CorrectTime(3)
+	s.Join(c, "Q", 1)
+	if err := s.Join(c, "Q", 1); err == nil {
+		t.Errorf("Expected error of second entry of transaction")
+	}
+	s.CorrectTime(5)
+	if time, _ := s.Depart(a, "Q"); time != 4 {
+		t.Errorf("Expected time 4 in queue, got %f", time)
+	}
+	if _, err := s.Depart(a, "Q"); err == nil {
+		t.Errorf("Expected error of departure of transaction which isn't in queue")
+	}
+	s.CorrectTime(10)
+
+	// Integral of content is 4 for A and 7 for C.
+	statistic := s.GetQueueStatistic("Q")
+	expected := QueueStatistic{"Q", 1, 2, 3, 1, 1.1, 11.0 / 3, 5.5}
+	if statistic.Content != expected.Content || statistic.Max != expected.Max || statistic.Entries != expected.Entries ||
+		statistic.ZeroEntries != expected.ZeroEntries || math.Abs(statistic.AverageContent-expected.AverageContent) > 1e-9 ||
+		math.Abs(statistic.AverageTime-expected.AverageTime) > 1e-9 || math.Abs(statistic.AverageNonZero-expected.AverageNonZero) > 1e-9 {
+		t.Errorf("Expected %+v, got %+v", expected, statistic)
+	}
+	if !s.InQueue(c, "Q") || s.InQueue(a, "Q") {
+		t.Errorf("Expected only transaction 3 in queue")
+	}
+	if names := s.GetQueues(); len(names) != 1 || names[0] != "Q" {
+		t.Errorf("Expected queue Q, got %v", names)
+	}
+}
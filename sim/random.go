@@ -0,0 +1,57 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Source of uniformly distributed random numbers in [0, 1).
+type Source interface {
+	Float64() float64
+}
+
+// Antithetic is a source which returns complementary number 1-U for each number U of wrapped source.
+type Antithetic struct {
+	Source
+}
+
+// Float64 returns complementary random number.
+func (a Antithetic) Float64() float64 {
+	return 1 - a.Source.Float64()
+}
+
+// Stream is a source which counts drawn numbers, so its state is restored by seed and number of draws.
+type Stream struct {
+	Seed, Draws int64
+	rand        *rand.Rand
+}
+
+// NewStream returns stream by seed.
+func NewStream(seed int64) *Stream {
+	return &Stream{seed, 0, rand.New(rand.NewSource(seed))}
+}
+
+// RestoreStream returns stream by seed which continues after specified number of draws.
+func RestoreStream(seed, draws int64) *Stream {
+	s := NewStream(seed)
+	for s.Draws < draws {
+		s.Float64()
+	}
+	return s
+}
+
+// Float64 returns next random number of stream.
+func (s *Stream) Float64() float64 {
+	s.Draws++
+	return s.rand.Float64()
+}
+
+// Exponential returns exponentially distributed random number with specified mean by inverse transform.
+func Exponential(r Source, mean float64) (float64, error) {
+	if mean <= 0 {
+		return 0.0, errors.New(fmt.Sprintf("incorrect mean in Exponential: %f", mean))
+	}
+	return -mean * math.Log(1-open(r.Float64())), nil
+}
@@ -0,0 +1,51 @@
+package sim
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAntithetic(t *testing.T) {
+	limits := Pair{35, 55}
+	r := rand.New(rand.NewSource(1))
+	a := Antithetic{rand.New(rand.NewSource(1))}
+
+	for i := 0; i < 10; i++ {
+		u, _ := Uniform(r, limits)
+		v, _ := Uniform(a, limits)
+		if sum := u + v; sum < 90-1e-9 || sum > 90+1e-9 {
+			t.Errorf("Expected sum of antithetic pair %.2f, got %.2f", 90.0, sum)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	s := NewStream(1)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		if u, v := s.Float64(), r.Float64(); u != v {
+			t.Errorf("Expected %f, got %f", v, u)
+		}
+	}
+	restored := RestoreStream(s.Seed, s.Draws)
+	for i := 0; i < 10; i++ {
+		if u, v := restored.Float64(), s.Float64(); u != v {
+			t.Errorf("Expected %f after restore, got %f", v, u)
+		}
+	}
+}
+
+func TestExponential(t *testing.T) {
+	if _, err := Exponential(rand.New(rand.NewSource(1)), 0); err == nil {
+		t.Errorf("Expected error for zero mean")
+	}
+	if v, err := Exponential(Antithetic{constant(1)}, 5); err != nil || v != 0 {
+		t.Errorf("Expected %.2f, got %.2f", 0.0, v)
+	}
+}
+
+type constant float64
+
+func (c constant) Float64() float64 {
+	return float64(c)
+}
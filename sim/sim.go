@@ -4,7 +4,6 @@ package sim
 import (
 	"errors"
 	"fmt"
-	"math/rand"
 	"simulation-modeling/statistic"
 )
 
@@ -20,6 +19,11 @@ type Pair struct {
 	Left, Right float64
 }
 
+// Pair of transaction and point which refused it because of length.
+type Rejection struct {
+	Id, Point int
+}
+
 // Simulator.
 type Sim struct {
 	points         int
@@ -30,20 +34,38 @@ type Sim struct {
 	pointStatistic []statistic.Unit
 	waitingList    []*Transaction
 	finish         bool
+	pointLength    []float64
+	rejections     map[Rejection]bool
+	observers      []Observer
+	queues         map[string]*queue
+	queueNames     []string
+	userChains     map[string]*userChain
+	pointChains    map[int]*userChain
+	interrupted    map[int]*interruption
+	families       map[int]*family
+	assemblies     map[string]map[int]*assembly
 }
 
 // New returns new simulator by specified number of points.
 // Simulator has a future event chain, a slice of statistic unit for each point and a waitlist of transactions.
 // Waitlist is slice with length 0 and capacity 10.
 func New(points int) *Sim {
-	return &Sim{points,
-		make([]int, points),
-		0,
-		0.0,
-		NewChain("FEC"),
-		make([]statistic.Unit, points),
-		make([]*Transaction, 0, 10),
-		true}
+	return &Sim{
+		points:         points,
+		pointState:     make([]int, points),
+		fec:            NewChain("FEC"),
+		pointStatistic: make([]statistic.Unit, points),
+		waitingList:    make([]*Transaction, 0, 10),
+		finish:         true,
+		pointLength:    make([]float64, points),
+		rejections:     make(map[Rejection]bool),
+		queues:         make(map[string]*queue),
+		userChains:     make(map[string]*userChain),
+		pointChains:    make(map[int]*userChain),
+		interrupted:    make(map[int]*interruption),
+		families:       make(map[int]*family),
+		assemblies:     make(map[string]map[int]*assembly),
+	}
 }
 
 // Init makes initiation of simulator.
@@ -57,8 +79,19 @@ func (s *Sim) Init() {
 
 // Generate creates new transaction in simulator by target waypoint.
 func (s *Sim) Generate(nextTime float64, targetPoint int) error {
+	_, err := s.GeneratePriority(nextTime, targetPoint, 0)
+	return err
+}
+
+// GeneratePriority creates and returns new transaction in simulator by target waypoint and priority.
+func (s *Sim) GeneratePriority(nextTime float64, targetPoint, priority int) (*Transaction, error) {
 	s.idCounter++
-	return s.fec.Insert(NewTransaction(s.idCounter, s.simTime+nextTime, targetPoint))
+	tr := NewTransaction(s.idCounter, s.simTime+nextTime, targetPoint)
+	tr.SetPriority(priority)
+	for _, o := range s.observers {
+		o.Generate(s.simTime, tr)
+	}
+	return tr, s.fec.Insert(tr)
 }
 
 // Advance moves transaction to next waypoint by specified time.
@@ -66,6 +99,12 @@ func (s *Sim) Advance(tr *Transaction, nextTime float64, nextPoint int) {
 	tr.CorrectTime(nextTime, nextPoint)
 }
 
+// Delay returns transaction to future event chain after specified time without change of waypoints.
+func (s *Sim) Delay(tr *Transaction, delayTime float64) error {
+	tr.Wait(delayTime)
+	return s.fec.Insert(tr)
+}
+
 // Test returns result of check of state of point.
 func (s *Sim) Test(listOfPoint []int) (bool, error) {
 	for _, point := range listOfPoint {
@@ -81,9 +120,16 @@ func (s *Sim) Test(listOfPoint []int) (bool, error) {
 
 // SeizePoint sets "Used" state of point.
 func (s *Sim) SeizePoint(p int) error {
+	return s.seize(nil, p)
+}
+
+func (s *Sim) seize(tr *Transaction, p int) error {
 	if p < s.points {
 		if s.pointState[p] != NAvailable {
 			s.pointState[p] = Used
+			for _, o := range s.observers {
+				o.Seize(s.simTime, tr, p)
+			}
 			return nil
 		} else {
 			return errors.New("point not available in Sim.SeizePoint")
@@ -93,11 +139,22 @@ func (s *Sim) SeizePoint(p int) error {
 	}
 }
 
-// SeizePoint sets "NUsed" state of point.
+// ReleasePoint sets "NUsed" state of point.
 func (s *Sim) ReleasePoint(p int) error {
+	return s.release(nil, p)
+}
+
+func (s *Sim) release(tr *Transaction, p int) error {
 	if p < s.points {
 		if s.pointState[p] != NAvailable {
 			s.pointState[p] = NUsed
+			for _, o := range s.observers {
+				o.Release(s.simTime, tr, p)
+			}
+			if ch, ok := s.pointChains[p]; ok && len(ch.transactions) != 0 {
+				_, err := s.unlink(ch, 0)
+				return err
+			}
 			return nil
 		} else {
 			return errors.New("point not available in Sim.ReleasePoint")
@@ -107,14 +164,40 @@ func (s *Sim) ReleasePoint(p int) error {
 	}
 }
 
+// GetPointState returns state of point.
+func (s *Sim) GetPointState(p int) (int, error) {
+	if p < 0 || p >= s.points {
+		return 0, errors.New("incorrect point's id in Sim.GetPointState")
+	}
+	return s.pointState[p], nil
+}
+
+// SetPointState sets any supported state of point.
+func (s *Sim) SetPointState(p int, state int) error {
+	if p < 0 || p >= s.points {
+		return errors.New("incorrect point's id in Sim.SetPointState")
+	}
+	if state != NAvailable && state != NUsed && state != Used {
+		return errors.New("incorrect state in Sim.SetPointState")
+	}
+	s.pointState[p] = state
+	return nil
+}
+
 // Terminate completes simulation.
 func (s *Sim) Terminate() {
 	s.finish = true
+	for _, o := range s.observers {
+		o.Terminate(s.simTime)
+	}
 }
 
 // AddToWaitlist adds transaction to waitlist.
 func (s *Sim) AddToWaitlist(tr *Transaction) int {
 	s.waitingList = append(s.waitingList, tr)
+	for _, o := range s.observers {
+		o.Wait(s.simTime, tr)
+	}
 	return len(s.waitingList)
 }
 
@@ -130,18 +213,65 @@ func (s *Sim) RemoveFromWaitlist(tr *Transaction) int {
 	}
 	if check {
 		s.waitingList = append(s.waitingList[:number], s.waitingList[number+1:]...)
+		for _, o := range s.observers {
+			o.Resume(s.simTime, tr)
+		}
 	}
 	return len(s.waitingList)
 }
 
+// SetPointLength sets length of point. Point with zero length accepts transaction of any length.
+func (s *Sim) SetPointLength(p int, length float64) error {
+	if p < s.points {
+		s.pointLength[p] = length
+		return nil
+	} else {
+		return errors.New("incorrect point's id in Sim.SetPointLength")
+	}
+}
+
+// Fits returns result of check of transaction's length against length of point.
+func (s *Sim) Fits(tr *Transaction, p int) (bool, error) {
+	if !(p < s.points) {
+		return false, errors.New("incorrect point's id in Sim.Fits")
+	}
+	return s.pointLength[p] == 0 || GetLength(*tr) <= s.pointLength[p], nil
+}
+
+// Reject counts transaction rejected by point because of length, each pair of transaction and point is counted once.
+func (s *Sim) Reject(tr *Transaction, p int) error {
+	if !(p < s.points) {
+		return errors.New("incorrect point's id in Sim.Reject")
+	}
+	s.rejections[Rejection{GetId(*tr), p}] = true
+	return nil
+}
+
+// GetRejections returns number of transactions rejected by point because of length.
+func (s *Sim) GetRejections(p int) int {
+	count := 0
+	for rejection := range s.rejections {
+		if rejection.Point == p {
+			count++
+		}
+	}
+	return count
+}
+
 // UsePoint releases current, seizes next waypoint and sets next waypoint for transaction,
+// Seize is refused if transaction doesn't fit next waypoint.
 func (s *Sim) UsePoint(tr *Transaction, nextTime float64, nextPoint int) error {
 	//fmt.Println("GEBUG PRINT IN USE: ", Tr)
 	points := GetPoints(*tr)
-	if err := s.ReleasePoint(points.Current); err != nil {
+	if fits, err := s.Fits(tr, points.Next); err != nil {
+		return err
+	} else if !fits {
+		return errors.New(fmt.Sprintf("transaction %d doesn't fit point %d in Sim.UsePoint", GetId(*tr), points.Next))
+	}
+	if err := s.release(tr, points.Current); err != nil {
 		return err
 	}
-	if err := s.SeizePoint(points.Next); err != nil {
+	if err := s.seize(tr, points.Next); err != nil {
 		return err
 	}
 	tr.CorrectTime(nextTime, nextPoint)
@@ -171,10 +301,18 @@ func (s *Sim) Extraction() ([]*Transaction, error) {
 		return nil, err
 	} else {
 		s.simTime = GetTime(*cec[0])
+		for _, o := range s.observers {
+			o.Extract(s.simTime, cec)
+		}
 		return cec, nil
 	}
 }
 
+// GetFuture returns transactions of future event chain sorted by time.
+func (s *Sim) GetFuture() []*Transaction {
+	return append([]*Transaction(nil), s.fec.chain...)
+}
+
 // GetWaitlist returns waitlist.
 func (s *Sim) GetWaitlist() []*Transaction {
 	return s.waitingList
@@ -199,6 +337,31 @@ func (s *Sim) GetStatistic(point int) (float64, float64, error) {
 	}
 }
 
+// KeepValues makes statistic units of points keep values added after call, they are needed for batch means.
+func (s *Sim) KeepValues() {
+	for p := range s.pointStatistic {
+		s.pointStatistic[p].Keep()
+	}
+}
+
+// GetCount returns number of statistic values for point.
+func (s *Sim) GetCount(point int) (int, error) {
+	if point < s.points {
+		return s.pointStatistic[point].Count(), nil
+	} else {
+		return 0, errors.New("incorrect point's id in Sim.GetCount")
+	}
+}
+
+// GetValues returns copy of sequence of statistic values for point, values are kept only after KeepValues.
+func (s *Sim) GetValues(point int) ([]float64, error) {
+	if point < s.points {
+		return s.pointStatistic[point].Values(), nil
+	} else {
+		return nil, errors.New("incorrect point's id in Sim.GetValues")
+	}
+}
+
 // IsFinish returns result of check of ending.
 func (s *Sim) IsFinish() bool {
 	if s.finish {
@@ -229,11 +392,31 @@ func (s *Sim) DebugString() string {
 	for _, tr := range s.waitingList {
 		log += fmt.Sprintf("\t%s\n", tr)
 	}
+	for _, tr := range s.GetInterrupted() {
+		remaining, _ := s.GetRemaining(tr.id)
+		log += fmt.Sprintf("INTERRUPTED %s, REMAINING: %f\n", tr, remaining)
+	}
+	for _, state := range s.assemblyStates() {
+		log += fmt.Sprintf("ASSEMBLING \"%s\" %s, ARRIVED: %d\n", state.Name, state.Transaction.transaction(), state.Arrived)
+	}
+	for _, state := range s.chainStates() {
+		if len(state.Transactions) == 0 {
+			continue
+		}
+		if state.Point < 0 {
+			log += fmt.Sprintf("USER CHAIN \"%s\", LENGTH: %d\n", state.Name, len(state.Transactions))
+		} else {
+			log += fmt.Sprintf("WAITING FOR POINT %d, LENGTH: %d\n", state.Point, len(state.Transactions))
+		}
+		for _, ts := range state.Transactions {
+			log += fmt.Sprintf("\t%s\n", ts.transaction())
+		}
+	}
 	return log
 }
 
 // Uniform returns uniformly distributed random number between specified limits.
-func Uniform(r *rand.Rand, limits Pair) (float64, error) {
+func Uniform(r Source, limits Pair) (float64, error) {
 	if limits.Left > limits.Right {
 		return 0.0, errors.New(fmt.Sprintf("incorrect limits in Uniform: (%f, %f)", limits.Left, limits.Right))
 	} else {
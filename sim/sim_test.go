@@ -0,0 +1,129 @@
+package sim
+
+import "testing"
+
+func TestFits(t *testing.T) {
+	s := New(3)
+	s.Init()
+	s.SetPointLength(1, 500)
+
+	tests := []struct {
+		length float64
+		point  int
+		fits   bool
+	}{
+		{400, 1, true},
+		{500, 1, true},
+		{600, 1, false},
+		{600, 2, true},
+	}
+
+	for i, test := range tests {
+		tr := NewTransaction(i, 0, test.point)
+		tr.SetLength(test.length)
+		if fits, err := s.Fits(tr, test.point); err != nil || fits != test.fits {
+			t.Errorf("Expected fit %t of length %.0f to point %d, got %t", test.fits, test.length, test.point, fits)
+		}
+	}
+	if r := s.GetRejections(1); r != 0 {
+		t.Errorf("Expected no rejections by check, got %d", r)
+	}
+
+	// Rejection of the same transaction by point is counted once.
+	tr := NewTransaction(10, 0, 1)
+	for _, p := range []int{1, 1, 2} {
+		if err := s.Reject(tr, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Reject(tr, 3); err == nil {
+		t.Errorf("Expected error of rejection by incorrect point")
+	}
+	if r := s.GetRejections(1); r != 1 {
+		t.Errorf("Expected %d rejection, got %d", 1, r)
+	}
+
+	tr = NewTransaction(11, 0, 1)
+	tr.SetLength(700)
+	if err := s.UsePoint(tr, 1.0, 2); err == nil {
+		t.Errorf("Expected refused seize of too short point")
+	}
+}
+
+func TestPointState(t *testing.T) {
+	s := New(2)
+	s.Init()
+	if err := s.SetPointState(1, NAvailable); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := s.GetPointState(1); state != NAvailable {
+		t.Errorf("Expected state %d, got %d", NAvailable, state)
+	}
+	if err := s.SeizePoint(1); err == nil {
+		t.Errorf("Expected refused seize of not available point")
+	}
+	if err := s.SetPointState(2, Used); err == nil {
+		t.Errorf("Expected error for incorrect point")
+	}
+	if err := s.SetPointState(0, 5); err == nil {
+		t.Errorf("Expected error for incorrect state")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	s := New(3)
+	s.Init()
+	s.Generate(5, 1)
+	s.Generate(5, 2)
+	s.Generate(2, 1)
+	cec, _ := s.Extraction()
+	s.UsePoint(cec[0], 3, 2)
+	s.AddStatistic(1, 0.1)
+	s.KeepValues()
+	s.AddStatistic(1, 0.2)
+	s.AddToWaitlist(NewTransaction(10, 1, 2))
+	s.Join(cec[0], "Q", 2)
+	s.Reject(cec[0], 1)
+
+	restored, err := Restore(s.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DebugString() != s.DebugString() {
+		t.Errorf("Expected state %s, got %s", s.DebugString(), restored.DebugString())
+	}
+	_, expected, _ := s.GetStatistic(1)
+	if _, sum, _ := restored.GetStatistic(1); sum != expected {
+		t.Errorf("Expected sum %f, got %f", expected, sum)
+	}
+	if values, _ := restored.GetValues(1); len(values) != 1 || values[0] != 0.2 {
+		t.Errorf("Expected kept values [0.2], got %v", values)
+	}
+	if count, _ := restored.GetCount(1); count != 2 {
+		t.Errorf("Expected %d values, got %d", 2, count)
+	}
+	if expected, statistic := s.GetQueueStatistic("Q"), restored.GetQueueStatistic("Q"); statistic != expected {
+		t.Errorf("Expected queue %+v, got %+v", expected, statistic)
+	}
+	if !restored.InQueue(cec[0], "Q") {
+		t.Errorf("Expected transaction %d in queue of restored simulator", GetId(*cec[0]))
+	}
+	if r := restored.GetRejections(1); r != 1 {
+		t.Errorf("Expected %d rejection, got %d", 1, r)
+	}
+	for len(s.GetFuture()) != 0 {
+		a, _ := s.Extraction()
+		b, _ := restored.Extraction()
+		if len(a) != len(b) || GetId(*a[0]) != GetId(*b[0]) {
+			t.Errorf("Expected head %s, got %s", a[0], b[0])
+		}
+	}
+	c := restored.Clone()
+	c.SeizePoint(0)
+	if state, _ := restored.GetPointState(0); state != NUsed {
+		t.Errorf("Expected state %d of original after change of clone, got %d", NUsed, state)
+	}
+	if _, err := Restore(Snapshot{Points: 2}); err == nil {
+		t.Errorf("Expected error for incorrect snapshot")
+	}
+}
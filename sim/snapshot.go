@@ -0,0 +1,261 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"simulation-modeling/statistic"
+	"sort"
+)
+
+// State of transaction in snapshot.
+type TransactionState struct {
+	Id, Current, Next int
+	Time, Lifetime    float64
+	Priority          int
+	Length            float64
+	Family            int `json:",omitempty"`
+}
+
+// State of queue in snapshot.
+type QueueState struct {
+	Name                               string
+	Content, Max, Entries, ZeroEntries int
+	Integral, Changed                  float64
+	Entered                            map[int]QueueEntry
+}
+
+// State of user chain or chain of point in snapshot, point of user chain is -1.
+type ChainState struct {
+	Name         string
+	Point        int
+	Order        int
+	Transactions []TransactionState
+}
+
+// State of interrupted transaction in snapshot.
+type InterruptState struct {
+	Transaction TransactionState
+	Remaining   float64
+}
+
+// State of family in snapshot with summary lifetime and number of disposed members.
+type FamilyState struct {
+	Id             int
+	Created, Ended float64
+	Members, Alive int
+	Lifetimes      float64
+	Disposed       int
+}
+
+// State of statistic unit of point in snapshot, values are in order of addition if they are kept.
+type StatisticState struct {
+	Sum    float64
+	Count  int
+	Keep   bool      `json:",omitempty"`
+	Values []float64 `json:",omitempty"`
+}
+
+// State of member of family held at assembly in snapshot.
+type AssemblyState struct {
+	Name        string
+	Arrived     int
+	Transaction TransactionState
+}
+
+// Snapshot of full state of simulator.
+type Snapshot struct {
+	Points      int
+	PointState  []int
+	IdCounter   int
+	SimTime     float64
+	Future      []TransactionState
+	Waitlist    []TransactionState
+	Statistic   []StatisticState
+	Finish      bool
+	PointLength []float64
+	Rejections  []Rejection
+	Queues      []QueueState     `json:",omitempty"`
+	Chains      []ChainState     `json:",omitempty"`
+	Interrupted []InterruptState `json:",omitempty"`
+	Families    []FamilyState    `json:",omitempty"`
+	Assemblies  []AssemblyState  `json:",omitempty"`
+}
+
+func (tr *Transaction) state() TransactionState {
+	return TransactionState{tr.id, tr.currentPoint, tr.nextPoint, tr.time, tr.lifetime, tr.priority, tr.length, tr.family}
+}
+
+func (ts TransactionState) transaction() *Transaction {
+	return &Transaction{ts.Id, ts.Current, ts.Next, ts.Time, ts.Lifetime, ts.Priority, ts.Length, ts.Family}
+}
+
+// Snapshot returns copy of state of simulator.
+func (s *Sim) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Points:      s.points,
+		PointState:  append([]int(nil), s.pointState...),
+		IdCounter:   s.idCounter,
+		SimTime:     s.simTime,
+		Statistic:   make([]StatisticState, s.points),
+		Finish:      s.finish,
+		PointLength: append([]float64(nil), s.pointLength...),
+	}
+	for _, tr := range s.fec.chain {
+		snapshot.Future = append(snapshot.Future, tr.state())
+	}
+	for _, tr := range s.waitingList {
+		snapshot.Waitlist = append(snapshot.Waitlist, tr.state())
+	}
+	for p := range s.pointStatistic {
+		u := &s.pointStatistic[p]
+		snapshot.Statistic[p] = StatisticState{u.Sum(), u.Count(), u.Kept(), u.Values()}
+	}
+	for rejection := range s.rejections {
+		snapshot.Rejections = append(snapshot.Rejections, rejection)
+	}
+	for _, name := range s.queueNames {
+		q := s.queues[name]
+		entered := make(map[int]QueueEntry, len(q.entered))
+		for id, entry := range q.entered {
+			entered[id] = entry
+		}
+		snapshot.Queues = append(snapshot.Queues, QueueState{name, q.content, q.max, q.entries, q.zeroEntries, q.integral, q.changed, entered})
+	}
+	snapshot.Chains = s.chainStates()
+	for _, tr := range s.GetInterrupted() {
+		snapshot.Interrupted = append(snapshot.Interrupted, InterruptState{tr.state(), s.interrupted[tr.id].remaining})
+	}
+	for _, id := range s.GetFamilies() {
+		f := s.families[id]
+		snapshot.Families = append(snapshot.Families, FamilyState{id, f.created, f.ended, f.members, f.alive,
+			f.lifetimes.Sum(), f.lifetimes.Count()})
+	}
+	snapshot.Assemblies = s.assemblyStates()
+	return snapshot
+}
+
+// assemblyStates returns states of held members of families sorted by name of assembly and id.
+func (s *Sim) assemblyStates() []AssemblyState {
+	names := make([]string, 0, len(s.assemblies))
+	for name := range s.assemblies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var states []AssemblyState
+	for _, name := range names {
+		for _, tr := range s.GetAssembling(name) {
+			states = append(states, AssemblyState{name, s.assemblies[name][GetFamily(*tr)].arrived, tr.state()})
+		}
+	}
+	return states
+}
+
+// chainStates returns states of user chains sorted by name and then of chains of points sorted by point.
+func (s *Sim) chainStates() []ChainState {
+	var states []ChainState
+	state := func(name string, p int, ch *userChain) {
+		cs := ChainState{name, p, ch.order, nil}
+		for _, tr := range ch.transactions {
+			cs.Transactions = append(cs.Transactions, tr.state())
+		}
+		states = append(states, cs)
+	}
+	names := make([]string, 0, len(s.userChains))
+	for name := range s.userChains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		state(name, -1, s.userChains[name])
+	}
+	points := make([]int, 0, len(s.pointChains))
+	for p := range s.pointChains {
+		points = append(points, p)
+	}
+	sort.Ints(points)
+	for _, p := range points {
+		state("", p, s.pointChains[p])
+	}
+	return states
+}
+
+// Restore returns simulator in state of snapshot.
+// Order of transactions with the same time in future event chain is kept.
+func Restore(snapshot Snapshot) (*Sim, error) {
+	if snapshot.Points < 1 || len(snapshot.PointState) != snapshot.Points || len(snapshot.Statistic) != snapshot.Points ||
+		len(snapshot.PointLength) != snapshot.Points {
+		return nil, errors.New(fmt.Sprintf("incorrect number of points in snapshot: %d", snapshot.Points))
+	}
+	s := New(snapshot.Points)
+	copy(s.pointState, snapshot.PointState)
+	copy(s.pointLength, snapshot.PointLength)
+	s.idCounter, s.simTime, s.finish = snapshot.IdCounter, snapshot.SimTime, snapshot.Finish
+	for i, ts := range snapshot.Future {
+		if i > 0 && ts.Time < snapshot.Future[i-1].Time {
+			return nil, errors.New("future event chain of snapshot is not sorted")
+		}
+		tr := ts.transaction()
+		if _, ok := s.fec.index[tr.id]; ok {
+			return nil, errors.New(fmt.Sprintf("transaction %d is repeated in future event chain of snapshot", tr.id))
+		}
+		s.fec.chain = append(s.fec.chain, tr)
+		s.fec.index[tr.id] = tr
+	}
+	for _, ts := range snapshot.Waitlist {
+		s.waitingList = append(s.waitingList, ts.transaction())
+	}
+	for p, state := range snapshot.Statistic {
+		s.pointStatistic[p] = statistic.NewUnit(state.Sum, state.Count, state.Keep, state.Values)
+	}
+	for _, rejection := range snapshot.Rejections {
+		s.rejections[rejection] = true
+	}
+	for _, state := range snapshot.Queues {
+		q := s.queue(state.Name)
+		q.content, q.max, q.entries, q.zeroEntries = state.Content, state.Max, state.Entries, state.ZeroEntries
+		q.integral, q.changed = state.Integral, state.Changed
+		for id, entry := range state.Entered {
+			q.entered[id] = entry
+		}
+	}
+	for _, state := range snapshot.Chains {
+		if err := checkOrder(state.Order); err != nil {
+			return nil, err
+		}
+		var ch *userChain
+		switch {
+		case state.Point < 0:
+			ch = s.chain(state.Name)
+		case state.Point < s.points:
+			ch = s.pointChain(state.Point)
+		default:
+			return nil, errors.New(fmt.Sprintf("incorrect point of chain in snapshot: %d", state.Point))
+		}
+		ch.order = state.Order
+		for _, ts := range state.Transactions {
+			ch.transactions = append(ch.transactions, ts.transaction())
+		}
+	}
+	for _, state := range snapshot.Interrupted {
+		s.interrupted[state.Transaction.Id] = &interruption{state.Transaction.transaction(), state.Remaining}
+	}
+	for _, state := range snapshot.Families {
+		f := &family{created: state.Created, members: state.Members, alive: state.Alive, ended: state.Ended,
+			lifetimes: statistic.NewUnit(state.Lifetimes, state.Disposed, false, nil)}
+		s.families[state.Id] = f
+	}
+	for _, state := range snapshot.Assemblies {
+		if _, ok := s.assemblies[state.Name]; !ok {
+			s.assemblies[state.Name] = make(map[int]*assembly)
+		}
+		tr := state.Transaction.transaction()
+		s.assemblies[state.Name][GetFamily(*tr)] = &assembly{tr, state.Arrived}
+	}
+	return s, nil
+}
+
+// Clone returns independent copy of simulator.
+func (s *Sim) Clone() *Sim {
+	c, _ := Restore(s.Snapshot())
+	return c
+}
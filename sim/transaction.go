@@ -13,11 +13,20 @@ type Points struct {
 type Transaction struct {
 	id, currentPoint, nextPoint int
 	time, lifetime              float64
+	priority                    int
+	length                      float64
+	// Id of root transaction of family created by split, 0 if transaction isn't in family.
+	family int
 }
 
 // New returns new transaction by id, initial value of timer and index of next waypoint.
 func NewTransaction(id int, time float64, nextPoint int) *Transaction {
-	return &Transaction{id, 0, nextPoint, time, 0}
+	return &Transaction{id, 0, nextPoint, time, 0, 0, 0, 0}
+}
+
+// SetPriority sets priority of transaction. Greater value means higher priority.
+func (tr *Transaction) SetPriority(priority int) {
+	tr.priority = priority
 }
 
 // CorrectTimer sets new value of time, new points for transaction and makes time shift.
@@ -57,3 +66,26 @@ func GetTime(tr Transaction) float64 {
 func GetPoints(tr Transaction) Points {
 	return Points{tr.currentPoint, tr.nextPoint}
 }
+
+// SetLength sets length of transaction which is checked against length of points.
+func (tr *Transaction) SetLength(length float64) {
+	tr.length = length
+}
+
+// GetPriority returns transaction's priority.
+func GetPriority(tr Transaction) int {
+	return tr.priority
+}
+
+// GetLength returns transaction's length.
+func GetLength(tr Transaction) float64 {
+	return tr.length
+}
+
+// GetFamily returns id of transaction's family, transaction which isn't split is family by itself.
+func GetFamily(tr Transaction) int {
+	if tr.family == 0 {
+		return tr.id
+	}
+	return tr.family
+}
@@ -10,9 +10,9 @@ type testPair struct {
 
 func TestCorrectTime(t *testing.T) {
 	tests := []testPair{
-		{New(0, 2.0, 1), 3.0, 2},
-		{New(0, 3.0, 3), 1.5, 2},
-		{New(1, 0.0, 0), 0.0, 0},
+		{NewTransaction(0, 2.0, 1), 3.0, 2},
+		{NewTransaction(0, 3.0, 3), 1.5, 2},
+		{NewTransaction(1, 0.0, 0), 0.0, 0},
 	}
 
 	for _, test := range tests {
@@ -38,9 +38,9 @@ func TestCorrectTime(t *testing.T) {
 
 func TestWait(t *testing.T) {
 	tests := []testPair{
-		{New(0, 2.0, 1), 3.0, 2},
-		{New(0, 3.0, 3), 1.5, 2},
-		{New(1, 0.0, 0), 0.0, 0},
+		{NewTransaction(0, 2.0, 1), 3.0, 2},
+		{NewTransaction(0, 3.0, 3), 1.5, 2},
+		{NewTransaction(1, 0.0, 0), 0.0, 0},
 	}
 
 	for _, test := range tests {
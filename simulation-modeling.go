@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
-	"math/rand"
+	"io"
 	"os"
 	"simulation-modeling/sim"
+	"simulation-modeling/statistic"
+	"strings"
 	"time"
 )
 
@@ -26,12 +28,19 @@ const ( // List of actions
 	Wait
 	Use
 	Terminate
+	Occupy
+	Release
 )
 const ( // List of limits
 	Station = iota
 	AC
 	BC
 	Timer
+	Delay
+	Length
+	CA     // transit from crossing loop to A
+	CB     // transit from B to crossing loop
+	Limits // first limit of built models
 )
 
 type Checks struct {
@@ -44,68 +53,335 @@ type Action struct {
 	Arguments []int
 }
 
-func GenerateUniform(S *sim.Sim, R *rand.Rand, Limits sim.Pair, PointList []int) {
+func GenerateRandom(S *sim.Sim, R sim.Source, Timing sim.Distribution, PointList []int, Priority int, Length sim.Distribution) {
 	for _, point := range PointList {
-		if time, err := sim.Uniform(R, Limits); err != nil {
+		if time, err := Timing.Sample(R); err != nil {
 			fmt.Println(err, S.DebugString())
 			os.Exit(1)
 		} else {
-			S.Generate(time, point)
-			if point != ClockPoint {
-				S.AddStatistic(Point0, time)
+			tr, err := S.GeneratePriority(time, point, Priority)
+			if err != nil {
+				fmt.Println(err, S.DebugString())
+				os.Exit(1)
 			}
+			SetLength(S, R, tr, Length)
+			S.AddStatistic(Point0, time)
 		}
 	}
 }
 
-func UseBlock(S *sim.Sim, Tr *sim.Transaction, Time float64, NextPoint int) {
+// SetLength sets length of transaction by distribution if it's specified.
+func SetLength(S *sim.Sim, R sim.Source, Tr *sim.Transaction, Length sim.Distribution) {
+	if Length == nil {
+		return
+	}
+	if length, err := Length.Sample(R); err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	} else {
+		Tr.SetLength(length)
+	}
+}
+
+// Choose returns first point of candidates for next waypoint which transaction fits,
+// which is free and isn't next waypoint of other transaction. Candidates which transaction
+// doesn't fit are counted as rejections. Single candidate is checked only for fit.
+func Choose(S *sim.Sim, Tr *sim.Transaction, Candidates []int) (int, bool) {
+	for _, point := range Candidates {
+		fits, err := S.Fits(Tr, point)
+		if err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+		if !fits {
+			if err := S.Reject(Tr, point); err != nil {
+				fmt.Println(err, S.DebugString())
+				os.Exit(1)
+			}
+		}
+		if len(Candidates) == 1 {
+			return point, fits
+		}
+		if !fits {
+			continue
+		}
+		if free, err := S.Test([]int{point}); err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		} else if free && !Reserved(S, Tr, point) {
+			return point, true
+		}
+	}
+	return 0, false
+}
+
+// Clear returns false if next waypoint of transaction is occupied point of section.
+// Checks of crossing loop clear section for train arriving at terminal station,
+// but not for train held there for free track of loop which it fits.
+func Clear(S *sim.Sim, M *Model, Points sim.Points) bool {
+	if _, ok := M.sectionIndex(Points.Next); !ok {
+		return true
+	}
+	free, err := S.Test([]int{Points.Next})
+	if err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	return free
+}
+
+// Oversized returns candidates of waypoint on route of new transaction which it doesn't fit,
+// such transaction would wait for them forever. Route is followed by first candidates of movements.
+func Oversized(S *sim.Sim, M *Model, Tr *sim.Transaction) []int {
+	points := sim.GetPoints(*Tr)
+	if points.Current != Point0 {
+		return nil
+	}
+	candidates := []int{points.Next}
+	for step := 0; step < M.Points; step++ {
+		fits := false
+		for _, point := range candidates {
+			if point == Point0 {
+				return nil
+			}
+			ok, err := S.Fits(Tr, point)
+			if err != nil {
+				fmt.Println(err, S.DebugString())
+				os.Exit(1)
+			}
+			fits = fits || ok
+		}
+		if !fits {
+			return candidates
+		}
+		candidates = nil
+		for _, action := range M.RoadMap[Checks{points.Current, points.Next, true}] {
+			if action.Type == Use {
+				candidates = action.Arguments[1:]
+			}
+		}
+		if candidates == nil {
+			return nil
+		}
+		points = sim.Points{points.Next, candidates[0]}
+	}
+	return nil
+}
+
+// Reserved returns true if point is next waypoint of other transaction.
+func Reserved(S *sim.Sim, Tr *sim.Transaction, Point int) bool {
+	for _, chain := range [][]*sim.Transaction{S.GetFuture(), S.GetWaitlist()} {
+		for _, other := range chain {
+			if other != Tr && sim.GetPoints(*other).Next == Point {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckPoints returns true if points are free and none of them is next waypoint of other transaction
+// which moved at current time: it enters the point in the same phase, before point is seized.
+func CheckPoints(S *sim.Sim, Tr *sim.Transaction, Points []int) (bool, error) {
+	if free, err := S.Test(Points); err != nil || !free {
+		return free, err
+	}
+	for _, other := range S.GetFuture() {
+		if sim.GetTime(*other) > S.GetSimTime() {
+			break
+		}
+		for _, point := range Points {
+			if other != Tr && sim.GetPoints(*other).Next == point {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// OccupyBlock seizes point, helper transaction releases it after time by timing.
+func OccupyBlock(S *sim.Sim, R sim.Source, Timing sim.Distribution, Point int) {
+	time, err := Timing.Sample(R)
+	if err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	if err := S.SeizePoint(Point); err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	if err := S.Generate(time, Point); err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+}
+
+func UseBlock(S *sim.Sim, M *Model, Tr *sim.Transaction, Time float64, NextPoint int) {
+	if M.Journal != nil {
+		M.Journal.Record(S, Tr, NextPoint)
+	}
 	if err := S.UsePoint(Tr, Time, NextPoint); err != nil {
 		fmt.Println(err, S.DebugString())
 		os.Exit(1)
 	}
+	if NextPoint == Point0 {
+		delete(M.ready, sim.GetId(*Tr))
+	} else if M.ready != nil {
+		M.ready[sim.GetId(*Tr)] = sim.GetTime(*Tr)
+	}
+}
+
+// queueName returns name of station queue of transaction leaving its waypoints: origin for new train
+// or station of current waypoint.
+func (M *Model) queueName(Points sim.Points) (string, bool) {
+	point := Points.Current
+	if point == Point0 {
+		point = Points.Next
+	}
+	if station, ok := M.stationIndex(point); ok {
+		return M.Stations[station].Name, true
+	}
+	return "", false
+}
+
+// JoinQueue adds transaction to queue of station it waits on.
+func JoinQueue(S *sim.Sim, M *Model, Tr *sim.Transaction) {
+	if name, ok := M.queueName(sim.GetPoints(*Tr)); ok && !S.InQueue(Tr, name) {
+		if err := S.Join(Tr, name, 1); err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+	}
+}
+
+// DepartQueue removes transaction from queue of station, points are waypoints before its movement.
+func DepartQueue(S *sim.Sim, M *Model, Tr *sim.Transaction, Points sim.Points) {
+	if name, ok := M.queueName(Points); ok && S.InQueue(Tr, name) {
+		if _, err := S.Depart(Tr, name); err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+	}
+}
+
+// filterActions returns generations of next arrivals if generations is set, other actions otherwise.
+func filterActions(Actions []Action, Generations bool) []Action {
+	var result []Action
+	for _, action := range Actions {
+		if (action.Type == Generate) == Generations {
+			result = append(result, action)
+		}
+	}
+	return result
 }
 
-func Phases(S *sim.Sim, R *rand.Rand, TimeTable map[int]sim.Pair, CheckTable map[sim.Points][]int, RoadMap map[Checks][]Action) {
+func Phases(S *sim.Sim, R sim.Source, M *Model, D Dispatcher) {
+	TimeTable, CheckTable, RoadMap := M.TimeTable, M.CheckTable, M.RoadMap
 	cec, err := S.Extraction()
 	if err != nil {
 		fmt.Println(err, S.DebugString())
 		os.Exit(1)
 	}
 	for _, tr := range cec {
+		if M.Passengers != nil && M.Passengers.Dwell(S, R, M, tr) {
+			continue
+		}
+		if M.Timetable != nil && M.Timetable.Hold(S, M, tr) {
+			continue
+		}
+		if M.Passengers != nil {
+			M.Passengers.Arrive(M, tr)
+		}
+		if M.Fleet != nil && M.Fleet.Arrive(S, R, M, tr) {
+			continue
+		}
 		points := sim.GetPoints(*tr)
-		check, err := S.Test(CheckTable[points])
+		check, err := CheckPoints(S, tr, CheckTable[points])
 		if err != nil {
 			fmt.Println(err, S.DebugString())
 			os.Exit(1)
 		}
 		actions := RoadMap[Checks{points.Current, points.Next, check}]
+		oversized := Oversized(S, M, tr)
+		for _, point := range oversized {
+			if err := S.Reject(tr, point); err != nil {
+				fmt.Println(err, S.DebugString())
+				os.Exit(1)
+			}
+		}
+		if oversized != nil || M.Fleet != nil && !M.Fleet.Depart(S, M, tr) {
+			// Train too long for line, cancelled train and unit which doesn't depart only generate next arrival.
+			actions = filterActions(actions, true)
+		}
+		if M.Fleet != nil && M.Fleet.Unit(tr) {
+			// Next arrival is generated by demand transaction which sent unit.
+			actions = filterActions(actions, false)
+		}
+		moved := false
 		for _, action := range actions {
 			if action.Type == Wait {
 				// GEBUG PRINT
 				//fmt.Println("WAIT ACTION", tr)
 				S.AddToWaitlist(tr)
+				JoinQueue(S, M, tr)
 			}
 			if action.Type == Generate {
 				// GEBUG PRINT
 				//fmt.Println("GENERATE ACTION")
-				GenerateUniform(S, R, TimeTable[action.Arguments[0]], []int{action.Arguments[1]})
+				priority := 0
+				if len(action.Arguments) > 2 {
+					priority = action.Arguments[2]
+				}
+				GenerateRandom(S, R, TimeTable[action.Arguments[0]], []int{action.Arguments[1]}, priority, TimeTable[Length])
 			}
 			if action.Type == Use {
 				// GEBUG PRINT
 				//fmt.Println("USE ACTION")
+				next, free := Choose(S, tr, action.Arguments[1:])
+				free = free && Clear(S, M, points)
+				JoinQueue(S, M, tr)
+				if !free {
+					S.AddToWaitlist(tr)
+					continue
+				}
+				moved = true
+				D.Dispatched(M, tr)
+				DepartQueue(S, M, tr, points)
+				standing := M.Standing(S, tr)
+				if standing > 0 && M.Energy != nil {
+					M.Energy.Wait(M, tr, points, standing)
+				}
 				switch {
 				case action.Arguments[0] == 0:
-					UseBlock(S, tr, 0.0, action.Arguments[1])
+					UseBlock(S, M, tr, 0.0, next)
 				default:
-					if time, err := sim.Uniform(R, TimeTable[action.Arguments[0]]); err != nil {
+					if M.Timetable != nil {
+						M.Timetable.Departed(S, M, tr, points)
+					}
+					if time, err := TimeTable[action.Arguments[0]].Sample(R); err != nil {
 						fmt.Println(err, S.DebugString())
 						os.Exit(1)
 					} else {
-						UseBlock(S, tr, time, action.Arguments[1])
+						if standing > 0 {
+							time += M.Penalty(points)
+						}
+						UseBlock(S, M, tr, time, next)
 						S.AddStatistic(points.Next, time)
+						if M.Energy != nil {
+							M.Energy.Transit(M, tr, points.Next, time)
+						}
 					}
 				}
 			}
+			if action.Type == Occupy && moved {
+				OccupyBlock(S, R, TimeTable[action.Arguments[0]], action.Arguments[1])
+			}
+			if action.Type == Release {
+				if err := S.ReleasePoint(action.Arguments[0]); err != nil {
+					fmt.Println(err, S.DebugString())
+					os.Exit(1)
+				}
+			}
 			if action.Type == Terminate {
 				// GEBUG PRINT
 				//fmt.Println("TERMINATE ACTION")
@@ -113,33 +389,60 @@ func Phases(S *sim.Sim, R *rand.Rand, TimeTable map[int]sim.Pair, CheckTable map
 			}
 		}
 	}
-	for i := 0; i < len(S.GetWaitlist()); i++ {
-		waitList := S.GetWaitlist()
+	// Whole waitlist is checked after each phase instead of chains of points: route of train depends on states
+	// of several points and on trains heading to them, and dispatcher orders all waiting trains together.
+	waitList := D.Order(S, M, append([]*sim.Transaction(nil), S.GetWaitlist()...))
+	for i := 0; i < len(waitList); i++ {
 		points := sim.GetPoints(*waitList[i])
-		check, err := S.Test(CheckTable[points])
+		check, err := CheckPoints(S, waitList[i], CheckTable[points])
 		if err != nil {
 			fmt.Println(err, S.DebugString())
 			os.Exit(1)
 		}
 		actions := RoadMap[Checks{points.Current, points.Next, check}]
+		moved := false
 		for _, action := range actions {
 			waitingTime := S.GetSimTime() - sim.GetTime(*waitList[i])
+			if action.Type == Occupy && moved {
+				OccupyBlock(S, R, TimeTable[action.Arguments[0]], action.Arguments[1])
+			}
 			if action.Type == Use {
 				// GEBUG PRINT
 				//fmt.Println("USE ACTION FOR WAITING TRANSACTION")
+				next, free := Choose(S, waitList[i], action.Arguments[1:])
+				free = free && Clear(S, M, points)
+				if !free {
+					continue
+				}
+				moved = true
+				D.Dispatched(M, waitList[i])
+				standing := M.Standing(S, waitList[i])
+				if standing > 0 && M.Energy != nil {
+					M.Energy.Wait(M, waitList[i], points, standing)
+				}
 				switch {
 				case action.Arguments[0] == 0:
-					UseBlock(S, waitList[i], waitingTime, action.Arguments[1])
+					UseBlock(S, M, waitList[i], waitingTime, next)
 				default:
-					if time, err := sim.Uniform(R, TimeTable[action.Arguments[0]]); err != nil {
+					if M.Timetable != nil {
+						M.Timetable.Departed(S, M, waitList[i], points)
+					}
+					if time, err := TimeTable[action.Arguments[0]].Sample(R); err != nil {
 						fmt.Println(err, S.DebugString())
 						os.Exit(1)
 					} else {
-						UseBlock(S, waitList[i], waitingTime+time, action.Arguments[1])
+						if standing > 0 {
+							time += M.Penalty(points)
+						}
+						UseBlock(S, M, waitList[i], waitingTime+time, next)
 						S.AddStatistic(points.Next, time)
+						if M.Energy != nil {
+							M.Energy.Transit(M, waitList[i], points.Next, time)
+						}
 					}
 				}
 				S.RemoveFromWaitlist(waitList[i])
+				DepartQueue(S, M, waitList[i], points)
 				if waitingTime != 0 {
 					if points.Current == Point0 {
 						S.AddStatistic(points.Next, waitingTime)
@@ -181,13 +484,264 @@ func GetSumTime(S *sim.Sim, Point int) float64 {
 	return 0.0
 }
 
+func GetStationTime(S *sim.Sim, Station ModelStation) float64 {
+	waitingTime := 0.0
+	for _, track := range Station.Tracks {
+		waitingTime += GetMeanTime(S, track)
+	}
+	return waitingTime / float64(len(Station.Tracks))
+}
+
+func GetInterval(S *sim.Sim, Point int, Batches int) string {
+	values, err := S.GetValues(Point)
+	if err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	}
+	var interval statistic.Interval
+	if Batches == 0 {
+		interval, err = statistic.AutoBatchMeans(values, 10, 0.1, 0.95)
+	} else {
+		interval, err = statistic.BatchMeans(values, Batches, 0.95)
+	}
+	if err != nil {
+		return fmt.Sprint("n/a, ", err)
+	}
+	return interval.String()
+}
+
+// MeanHeadway returns expected mean of headways of all arrivals at terminal stations. Terminal with shorter
+// headway has more arrivals, so the mean is weighted by arrival rates of terminals.
+func MeanHeadway(M *Model) float64 {
+	rate, terminals := 0.0, 0
+	for _, station := range M.Stations {
+		if station.Terminal {
+			rate += 1 / M.TimeTable[station.Headway].Mean()
+			terminals++
+		}
+	}
+	return float64(terminals) / rate
+}
+
+func GetEstimate(Y, X []float64, Mean float64, Antithetic, Control bool) string {
+	estimate, err := statistic.CrudeMean(Y)
+	if Antithetic && err == nil {
+		estimate, err = statistic.AntitheticMean(Y)
+		Y, X = statistic.PairMeans(Y), statistic.PairMeans(X)
+	}
+	if Control && err == nil {
+		reduction := estimate.Reduction
+		estimate, err = statistic.ControlVariateMean(Y, X, Mean)
+		estimate.Reduction *= reduction
+	}
+	if err != nil {
+		return fmt.Sprint("n/a, ", err)
+	}
+	return fmt.Sprintf("%.2f ± %.2f, variance reduction factor %.2f", estimate.Mean, estimate.HalfWidth(0.95), estimate.Reduction)
+}
+
+func StationLabel(Station ModelStation) string {
+	if Station.Terminal {
+		return "station " + Station.Name
+	}
+	return "crossing " + Station.Name
+}
+
+func NewStream(Seed int64, Replication int, Antithetic bool) sim.Source {
+	if !Antithetic {
+		return sim.NewStream(Seed + int64(Replication))
+	}
+	r := sim.NewStream(Seed + int64(Replication/2))
+	if Replication%2 == 1 {
+		return sim.Antithetic{r}
+	}
+	return r
+}
+
+// Start returns simulator with initial transactions of model.
+func Start(R sim.Source, M *Model, Trace []Arrival) *sim.Sim {
+	S := sim.New(M.Points)
+	S.Init()
+	if M.KeepValues {
+		S.KeepValues()
+	}
+	for point, length := range M.Lengths {
+		if err := S.SetPointLength(point, length); err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+	}
+
+	if duration, err := M.TimeTable[Timer].Sample(R); err != nil {
+		fmt.Println(err, S.DebugString())
+		os.Exit(1)
+	} else {
+		S.Generate(duration, M.Clock)
+	}
+	if M.Timetable != nil {
+		M.Timetable.Generate(S, R, M, M.TimeTable[Delay], M.TimeTable[Length])
+	}
+	M.ready = make(map[int]float64)
+	if M.Energy != nil {
+		M.Energy.Reset(M)
+	}
+	if M.Fleet != nil {
+		M.Fleet.Reset(M)
+	}
+	if M.Passengers != nil {
+		M.Passengers.Reset(M)
+	}
+	if M.Journal != nil {
+		M.Journal.Reset()
+	}
+	if M.Checkpoints != nil {
+		M.Checkpoints.Reset(0)
+	}
+	traced := GenerateTrace(S, Trace)
+	if M.Timetable != nil {
+		for point := range M.Timetable.Stations(M) {
+			traced[point] = true
+		}
+	}
+	if M.Fleet != nil {
+		M.Fleet.Generate(S, R, M)
+	}
+	for _, station := range M.Stations {
+		if station.Terminal && !traced[station.Tracks[0]] {
+			GenerateRandom(S, R, M.TimeTable[station.Headway], []int{station.Tracks[0]}, station.Priority, M.TimeTable[Length])
+		}
+	}
+	return S
+}
+
+func Simulate(R sim.Source, M *Model, D Dispatcher, Trace []Arrival) *sim.Sim {
+	return Continue(Start(R, M, Trace), R, M, D)
+}
+
+// Continue runs simulation until its end.
+func Continue(S *sim.Sim, R sim.Source, M *Model, D Dispatcher) *sim.Sim {
+	for !S.IsFinish() {
+		Phases(S, R, M, D)
+		//fmt.Println(S)
+		if M.Checkpoints != nil {
+			M.Checkpoints.Save(S, R, M, D)
+		}
+		if M.Animation != nil {
+			M.Animation.Frame(S, M)
+		}
+	}
+	return S
+}
+
+func WriteReport(Writer *bufio.Writer, S *sim.Sim, M *Model, D Dispatcher, Duration float64, Batches int) {
+	WriteData(Writer, fmt.Sprintf("%s simulation statistic\n", M.Title))
+	WriteData(Writer, fmt.Sprintf("Duration: %.0f minutes\n", Duration*60))
+	WriteData(Writer, fmt.Sprintf("Dispatching policy: %s\n", D.Name()))
+	for _, terminal := range []bool{true, false} {
+		for _, station := range M.Stations {
+			if station.Terminal == terminal {
+				WriteData(Writer, fmt.Sprintf("Mean waiting time on %s: %.2f\n", StationLabel(station), GetStationTime(S, station)))
+			}
+		}
+	}
+	for _, terminal := range []bool{true, false} {
+		for _, station := range M.Stations {
+			if station.Terminal == terminal {
+				q := S.GetQueueStatistic(station.Name)
+				WriteData(Writer, fmt.Sprintf("Queue on %s: max %d, mean content %.2f, entries %d, zero entries %d, mean time %.2f, mean time without zero entries %.2f\n",
+					StationLabel(station), q.Max, q.AverageContent, q.Entries, q.ZeroEntries, q.AverageTime, q.AverageNonZero))
+			}
+		}
+	}
+	for _, section := range M.Sections {
+		sumTime := 0.0
+		for _, point := range section.Points {
+			sumTime += GetSumTime(S, point)
+		}
+		WriteData(Writer, fmt.Sprintf("Utilization ratio for %s track: %.2f\n", section.Name, sumTime/(Duration*60*float64(section.Blocks))))
+	}
+	for _, section := range M.Sections {
+		if running := section.Running; running[0].Time != 0 {
+			WriteData(Writer, fmt.Sprintf("Running time on %s: %.2f forward, %.2f backward, start penalty %.2f and %.2f, stop penalty %.2f and %.2f\n",
+				section.Name, running[0].Time, running[1].Time, running[0].Start, running[1].Start, running[0].Stop, running[1].Stop))
+		}
+	}
+	if len(M.Lengths) != 0 {
+		for _, station := range M.Stations {
+			rejections := 0
+			for _, track := range station.Tracks {
+				rejections += S.GetRejections(track)
+			}
+			WriteData(Writer, fmt.Sprintf("Rejections by length on %s: %d\n", StationLabel(station), rejections))
+		}
+	}
+	if Batches < 0 {
+		return
+	}
+	WriteData(Writer, "Batch means estimates, 95% confidence\n")
+	for _, station := range M.Stations {
+		if station.Terminal {
+			WriteData(Writer, fmt.Sprintf("Waiting time on %s: %s\n", StationLabel(station), GetInterval(S, station.Tracks[0], Batches)))
+			continue
+		}
+		for i, track := range station.Tracks {
+			WriteData(Writer, fmt.Sprintf("Waiting time on %s track %d: %s\n", StationLabel(station), i+1, GetInterval(S, track, Batches)))
+		}
+	}
+	for _, section := range M.Sections {
+		if len(section.Points) == 1 {
+			WriteData(Writer, fmt.Sprintf("Transit time on %s track: %s\n", section.Name, GetInterval(S, section.Points[0], Batches)))
+			continue
+		}
+		for i, point := range section.Points {
+			direction, _ := M.directions[point]
+			WriteData(Writer, fmt.Sprintf("Transit time on %s track, direction %d block %d: %s\n", section.Name, direction+1, i%(len(section.Points)/2)+1, GetInterval(S, point, Batches)))
+		}
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fit" {
+		Fit(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		Debug(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "whatif" {
+		WhatIf(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "gpss" {
+		GPSS(os.Args[2:])
+		return
+	}
+
 	duration := 24.0
 	outFile := os.Stdout
 	defer outFile.Close()
 
 	outputFlag := flag.String("o", "", "write output to file")
+	configFlag := flag.String("c", "", "read model configuration from file")
 	durationFlag := flag.Float64("d", 24, "set simulation duration in hours")
+	replicationsFlag := flag.Int("r", 1, "set number of independent replications")
+	seedFlag := flag.Int64("s", 0, "set seed of random streams (default: current time)")
+	antitheticFlag := flag.Bool("antithetic", false, "pair replications with antithetic variates")
+	controlFlag := flag.Bool("control", false, "use mean headway as control variate for replications")
+	traceFlag := flag.String("trace", "", "read recorded arrivals from CSV file with time, origin and optional attributes")
+	traceStationsFlag := flag.String("trace-stations", "", "set comma-separated stations with trace-driven arrivals (default: all stations of recorded arrivals)")
+	dispatchFlag := flag.String("dispatch", "fifo", "set dispatching policy: fifo, priority, alternate or lookahead")
+	timetableFlag := flag.String("timetable", "", "read planned trains from CSV file with train, station, arrival and departure")
+	batchFlag := flag.Int("b", -1, "estimate 95% confidence intervals by batch means with specified number of batches (0 for automatic sizing)")
+	graphFlag := flag.String("graph", "", "write train graph of the first run to SVG file")
+	ganttFlag := flag.String("gantt", "", "write occupation chart of points of the first run to SVG file")
+	animateFlag := flag.Bool("animate", false, "animate the first run in terminal")
+	speedFlag := flag.Float64("speed", 10, "set speed of animation in simulation minutes per second")
+	windowFlag := flag.String("window", "", "set time window of charts as FROM,TO in minutes or HH:MM (default: whole run)")
+	checkpointFlag := flag.Float64("checkpoint-every", 0, "write checkpoint of the first run to file checkpoint-MINUTES.json after each period in minutes")
+	processFlag := flag.Bool("process", false, "run crossing loop model written as processes of trains instead of table model")
+	resumeFlag := flag.String("resume", "", "resume the first run from checkpoint file, model and options must be the same as in saved run")
 	flag.Parse()
 	if *outputFlag != "" {
 		if file, err := os.Create(*outputFlag); err != nil {
@@ -209,79 +763,196 @@ func main() {
 
 	// Init section
 
-	timings := map[int]sim.Pair{
-		Station: sim.Pair{35, 55},
-		AC:      sim.Pair{12, 18},
-		BC:      sim.Pair{17, 23},
-		Timer:   sim.Pair{duration * 60, duration * 60}}
-
-	checks := map[sim.Points][]int{
-		sim.Points{Point0, PointA}:   []int{PointAC, PointCm, PointCr},
-		sim.Points{Point0, PointB}:   []int{PointBC, PointCm, PointCr},
-		sim.Points{PointA, PointAC}:  []int{PointBC},
-		sim.Points{PointCr, PointBC}: []int{PointBC},
-		sim.Points{PointCm, PointBC}: []int{PointBC},
-		sim.Points{PointB, PointCr}:  []int{PointAC},
-		sim.Points{PointCr, PointAC}: []int{PointAC},
-		sim.Points{PointCm, PointAC}: []int{PointAC},
-	}
-
-	transfers := map[Checks][]Action{
-		{Point0, PointA, false}:   []Action{Action{Wait, []int{}}, Action{Generate, []int{Station, PointA}}},          // >A****C****B
-		{Point0, PointA, true}:    []Action{Action{Use, []int{0, PointAC}}, Action{Generate, []int{Station, PointA}}}, // >A****C****B
-		{PointA, PointAC, false}:  []Action{Action{Use, []int{AC, PointCr}}},                                          // A>***Cr****B
-		{PointA, PointAC, true}:   []Action{Action{Use, []int{AC, PointCm}}},                                          // A>***Cm****B
-		{PointAC, PointCm, true}:  []Action{Action{Use, []int{0, PointBC}}},                                           //
-		{PointAC, PointCr, true}:  []Action{Action{Use, []int{0, PointBC}}},                                           //
-		{PointCm, PointBC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cm<***B
-		{PointCm, PointBC, true}:  []Action{Action{Use, []int{BC, PointB}}},                                           // A****Cm>***B
-		{PointCr, PointBC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cr<***B
-		{PointCr, PointBC, true}:  []Action{Action{Use, []int{BC, PointB}}},                                           // A****Cr>***B
-		{PointBC, PointB, true}:   []Action{Action{Use, []int{0, Point0}}},                                            // A****C***->B
-
-		{Point0, PointB, false}:   []Action{Action{Wait, []int{}}, Action{Generate, []int{Station, PointB}}},          // A****C****B<
-		{Point0, PointB, true}:    []Action{Action{Use, []int{0, PointBC}}, Action{Generate, []int{Station, PointB}}}, // A****C****B<
-		{PointB, PointBC, false}:  []Action{Action{Use, []int{BC, PointCr}}},                                          // A****Cr***<B
-		{PointB, PointBC, true}:   []Action{Action{Use, []int{BC, PointCm}}},                                          // A****Cm***<B
-		{PointBC, PointCm, true}:  []Action{Action{Use, []int{0, PointAC}}},                                           //
-		{PointBC, PointCr, true}:  []Action{Action{Use, []int{0, PointAC}}},                                           //
-		{PointCm, PointAC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cm<***B
-		{PointCm, PointAC, true}:  []Action{Action{Use, []int{AC, PointA}}},                                           // A****Cm>***B
-		{PointCr, PointAC, false}: []Action{Action{Wait, []int{}}},                                                    // A***>Cr<***B
-		{PointCr, PointAC, true}:  []Action{Action{Use, []int{AC, PointA}}},                                           // A****Cr>***B
-		{PointAC, PointA, true}:   []Action{Action{Use, []int{0, Point0}}},                                            // A<-***C****B
-
-		{Point0, ClockPoint, true}: []Action{Action{Terminate, []int{}}}, // Clock
+	config := DefaultConfig()
+	if *configFlag != "" {
+		var err error
+		if config, err = LoadConfig(*configFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	model, err := config.Model(duration)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	model.KeepValues = *batchFlag >= 0
+
+	dispatcher, err := NewDispatcher(*dispatchFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if model.Fleet != nil && (*traceFlag != "" || *timetableFlag != "") {
+		fmt.Println("fleet can't be combined with trace or timetable")
+		os.Exit(1)
+	}
+	// Known mean of control variate is mean of headway timings, arrivals by other sources would bias estimate.
+	if *controlFlag && (*traceFlag != "" || *timetableFlag != "" || model.Fleet != nil) {
+		fmt.Println("control variate can't be combined with trace, timetable or fleet")
+		os.Exit(1)
+	}
+	var trace []Arrival
+	if *traceFlag != "" {
+		if trace, err = ReadTrace(*traceFlag, model); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		var names []string
+		for _, name := range strings.Split(*traceStationsFlag, ",") {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		stations, err := TraceStations(trace, names, model)
+		if err != nil {
+			fmt.Println(*traceFlag, err)
+			os.Exit(1)
+		}
+		trace = FilterTrace(trace, stations)
+		if err := ValidateTrace(trace, 0); err != nil {
+			fmt.Println(*traceFlag, err)
+			os.Exit(1)
+		}
+		model.RoadMap = TraceRoadMap(model.RoadMap, stations)
+	}
+	if *timetableFlag != "" {
+		if model.Timetable, err = ReadTimetable(*timetableFlag, model); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		model.RoadMap = TraceRoadMap(model.RoadMap, model.Timetable.Stations(model))
+	}
+	from, to := 0.0, duration*60
+	if *windowFlag != "" {
+		bounds := strings.Split(*windowFlag, ",")
+		if len(bounds) != 2 {
+			fmt.Printf("incorrect time window: %q\n", *windowFlag)
+			os.Exit(1)
+		}
+		var errFrom, errTo error
+		from, errFrom = ParseClock(strings.TrimSpace(bounds[0]))
+		to, errTo = ParseClock(strings.TrimSpace(bounds[1]))
+		if errFrom != nil || errTo != nil || from >= to {
+			fmt.Printf("incorrect time window: %q\n", *windowFlag)
+			os.Exit(1)
+		}
+	}
+	if *graphFlag != "" || *ganttFlag != "" {
+		model.Journal = &Journal{}
+	}
+	simulate := func(R sim.Source) *sim.Sim { return Simulate(R, model, dispatcher, trace) }
+	if *processFlag {
+		if *configFlag != "" || *traceFlag != "" || *timetableFlag != "" || model.Journal != nil || *checkpointFlag != 0 || *resumeFlag != "" {
+			fmt.Println("process model is crossing loop without configuration, trace, timetable, charts and checkpoints")
+			os.Exit(1)
+		}
+		simulate = func(R sim.Source) *sim.Sim { return SimulateProcesses(R, model, dispatcher) }
 	}
 	writer := bufio.NewWriter(outFile)
 
-	rand := rand.New(rand.NewSource(time.Now().UnixNano()))
-	CLSim := sim.New(Points)
-	CLSim.Init()
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var checkpoint *Checkpoint
+	if *resumeFlag != "" {
+		if checkpoint, err = LoadCheckpoint(*resumeFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		seed = checkpoint.Seed
+	}
+	if *checkpointFlag < 0 {
+		fmt.Printf("incorrect period of checkpoints: %.1f\n", *checkpointFlag)
+		os.Exit(1)
+	} else if *checkpointFlag > 0 {
+		model.Checkpoints = &Checkpoints{Every: *checkpointFlag, Prefix: "checkpoint-"}
+	}
 
 	// Begin simulation
 
-	GenerateUniform(CLSim, rand, timings[Timer], []int{ClockPoint})
-	GenerateUniform(CLSim, rand, timings[Station], []int{PointA, PointB})
-
-	for !CLSim.IsFinish() {
-		Phases(CLSim, rand, timings, checks, transfers)
-		//fmt.Println(CLSim)
+	if *animateFlag {
+		if *speedFlag <= 0 {
+			fmt.Printf("incorrect speed of animation: %.1f\n", *speedFlag)
+			os.Exit(1)
+		}
+		model.Animation = NewAnimation(*speedFlag, os.Stdout)
+	}
+	var CLSim *sim.Sim
+	if checkpoint != nil {
+		S, R, err := checkpoint.Restore(model, dispatcher)
+		if err != nil {
+			fmt.Println(*resumeFlag, err)
+			os.Exit(1)
+		}
+		fmt.Printf("> Simulation resumed at %.1f\n", S.GetSimTime())
+		CLSim = Continue(S, R, model, dispatcher)
+	} else {
+		CLSim = simulate(NewStream(seed, 0, *antitheticFlag))
+	}
+	model.Checkpoints = nil
+	if model.Animation != nil {
+		model.Animation.Close()
+		model.Animation = nil
 	}
 
 	// Get statistic
-	WriteData(writer, "Crossing loop simulation statistic\n")
-	WriteData(writer, fmt.Sprintf("Duration: %.0f minutes\n", duration*60))
-	WriteData(writer, fmt.Sprintf("Mean waiting time on station A: %.2f\n", GetMeanTime(CLSim, PointA)))
-	WriteData(writer, fmt.Sprintf("Mean waiting time on station B: %.2f\n", GetMeanTime(CLSim, PointB)))
-	waitingTime := GetMeanTime(CLSim, PointCm)
-	waitingTime += GetMeanTime(CLSim, PointCr)
-	WriteData(writer, fmt.Sprintf("Mean waiting time on crossing: %.2f\n", waitingTime/2))
-	WriteData(writer, fmt.Sprintf("Utilization ratio for AC track: %.2f\n", GetSumTime(CLSim, PointAC)/(duration*60)))
-	WriteData(writer, fmt.Sprintf("Utilization ratio for BC track: %.2f\n", GetSumTime(CLSim, PointBC)/(duration*60)))
-
-	err := writer.Flush()
-	if err != nil {
+	WriteReport(writer, CLSim, model, dispatcher, duration, *batchFlag)
+	if model.Timetable != nil {
+		WriteTimetableReport(writer, model.Timetable, model)
+	}
+	if model.Energy != nil {
+		WriteEnergyReport(writer, model.Energy, model)
+	}
+	if model.Fleet != nil {
+		WriteFleetReport(writer, model.Fleet, model, duration)
+	}
+	if model.Passengers != nil {
+		WritePassengerReport(writer, model.Passengers, model)
+	}
+	for _, chart := range []struct {
+		file  string
+		write func(io.Writer, *Model, *Journal, float64, float64) error
+	}{{*graphFlag, WriteTrainGraph}, {*ganttFlag, WriteGantt}} {
+		if chart.file == "" {
+			continue
+		}
+		file, err := os.Create(chart.file)
+		if err == nil {
+			err = chart.write(file, model, model.Journal, from, to)
+			file.Close()
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if *replicationsFlag > 1 {
+		headway := make([]float64, 0, *replicationsFlag)
+		waitingTime := make([][]float64, len(model.Stations))
+		for i := 0; i < *replicationsFlag; i++ {
+			if i > 0 {
+				dispatcher, _ = NewDispatcher(*dispatchFlag)
+				CLSim = simulate(NewStream(seed, i, *antitheticFlag))
+			}
+			headway = append(headway, GetMeanTime(CLSim, Point0))
+			for j, station := range model.Stations {
+				waitingTime[j] = append(waitingTime[j], GetStationTime(CLSim, station))
+			}
+		}
+		meanHeadway := MeanHeadway(model)
+		WriteData(writer, fmt.Sprintf("Replications: %d, seed: %d, antithetic: %t, control variate: %t\n",
+			*replicationsFlag, seed, *antitheticFlag, *controlFlag))
+		for j, station := range model.Stations {
+			WriteData(writer, fmt.Sprintf("Mean waiting time on %s: %s\n", StationLabel(station),
+				GetEstimate(waitingTime[j], headway, meanHeadway, *antitheticFlag, *controlFlag)))
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"simulation-modeling/sim"
+	"sort"
+	"testing"
+)
+
+// testModel returns crossing loop model or line with default timings for run of specified hours.
+func testModel(t *testing.T, Line *LineConfig, Hours float64) *Model {
+	config := DefaultConfig()
+	config.Line = Line
+	M, err := config.Model(Hours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return M
+}
+
+// simulate runs model by seed with journal of movements.
+func simulate(M *Model, D Dispatcher, Seed int64) *sim.Sim {
+	M.Journal = &Journal{}
+	return Simulate(NewStream(Seed, 0, false), M, D, nil)
+}
+
+// checkOccupancy fails test if track of passing loop or block of section is occupied by two trains at the same time.
+// Terminal stations aren't checked, trains queue on them before they enter line.
+func checkOccupancy(t *testing.T, M *Model, S *sim.Sim) {
+	var occupancies []Occupancy
+	for _, occupancy := range M.Journal.Occupancies(S.GetSimTime()) {
+		if i, ok := M.stationIndex(occupancy.Point); !ok || !M.Stations[i].Terminal {
+			occupancies = append(occupancies, occupancy)
+		}
+	}
+	sort.SliceStable(occupancies, func(i, j int) bool {
+		if occupancies[i].Point != occupancies[j].Point {
+			return occupancies[i].Point < occupancies[j].Point
+		}
+		return occupancies[i].Start < occupancies[j].Start
+	})
+	for i := 1; i < len(occupancies); i++ {
+		previous, current := occupancies[i-1], occupancies[i]
+		// Time of train released from waitlist is its time plus waiting time, it may be less than current time by rounding.
+		if previous.Point == current.Point && current.Start < previous.End-1e-9 {
+			t.Errorf("Trains %d and %d occupy %s at %.2f", previous.Id, current.Id, PointLabel(M, current.Point), current.Start)
+		}
+	}
+}
+
+// trains returns number of trains which entered line from terminal stations.
+func trains(M *Model) int {
+	count := 0
+	for _, movement := range M.Journal.Movements {
+		if movement.From == Point0 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		length     float64
+		candidates []int
+		point      int
+		ok         bool
+		rejections int
+	}{
+		{400, []int{PointCm, PointCr}, PointCm, true, 0},
+		// Long train is sent to longer track of loop.
+		{600, []int{PointCm, PointCr}, PointCr, true, 1},
+		{800, []int{PointCm, PointCr}, 0, false, 2},
+		{600, []int{PointCm}, PointCm, false, 1},
+		{800, []int{PointBC}, PointBC, true, 0},
+	}
+
+	for _, test := range tests {
+		S := sim.New(Points)
+		S.Init()
+		S.SetPointLength(PointCm, 500)
+		S.SetPointLength(PointCr, 700)
+		tr := waiting(1, 0, 0, sim.Points{PointA, PointAC})
+		tr.SetLength(test.length)
+		point, ok := Choose(S, tr, test.candidates)
+		if point != test.point || ok != test.ok {
+			t.Errorf("Expected point %d and %t for length %.0f of %v, got %d and %t", test.point, test.ok, test.length, test.candidates, point, ok)
+		}
+		if rejections := S.GetRejections(PointCm) + S.GetRejections(PointCr); rejections != test.rejections {
+			t.Errorf("Expected %d rejections for length %.0f, got %d", test.rejections, test.length, rejections)
+		}
+	}
+}
+
+func TestOversized(t *testing.T) {
+	M := testModel(t, nil, 24)
+	S := sim.New(M.Points)
+	S.Init()
+	S.SetPointLength(PointCm, 500)
+	S.SetPointLength(PointCr, 700)
+	tests := []struct {
+		length    float64
+		points    sim.Points
+		oversized []int
+	}{
+		{600, sim.Points{Point0, PointA}, nil},
+		{800, sim.Points{Point0, PointA}, []int{PointCm, PointCr}},
+		{800, sim.Points{Point0, PointB}, []int{PointCm, PointCr}},
+		// Train already on line isn't checked.
+		{800, sim.Points{PointA, PointAC}, nil},
+	}
+
+	for _, test := range tests {
+		tr := waiting(1, 0, 0, test.points)
+		tr.SetLength(test.length)
+		if oversized := Oversized(S, M, tr); !reflect.DeepEqual(oversized, test.oversized) {
+			t.Errorf("Expected oversized %v for length %.0f on %v, got %v", test.oversized, test.length, test.points, oversized)
+		}
+	}
+}
+
+func TestOversizedRun(t *testing.T) {
+	config := DefaultConfig()
+	config.Timings["length"] = DistributionConfig{"uniform", []float64{400, 800}}
+	config.TrackLengths = map[string][]float64{"C": {500, 700}}
+	M, err := config.Model(48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	S := simulate(M, FIFODispatcher{}, 1)
+	checkOccupancy(t, M, S)
+
+	// Trains longer than both tracks of loop are rejected at terminals and don't block line.
+	if rejections := S.GetRejections(PointCr); rejections == 0 {
+		t.Errorf("Expected trains longer than every track rejected, got none")
+	}
+	if n := trains(M); n < 30 {
+		t.Errorf("Expected more than 30 trains in 48 hours, got %d", n)
+	}
+	for _, tr := range S.GetWaitlist() {
+		if sim.GetLength(*tr) > 700 {
+			t.Errorf("Expected no train longer than loop in waitlist, got %v", tr)
+		}
+	}
+}
+
+func TestMeanHeadway(t *testing.T) {
+	stations := []StationConfig{{Name: "A", Tracks: 1, Headway: &DistributionConfig{"uniform", []float64{5, 15}}}, {Name: "C", Tracks: 2},
+		{Name: "B", Tracks: 1, Headway: &DistributionConfig{"uniform", []float64{20, 40}}}}
+	sections := []SectionConfig{{Transit: testTransit}, {Transit: testTransit}}
+	tests := []struct {
+		model *Model
+		mean  float64
+	}{
+		{testModel(t, nil, 240), testModel(t, nil, 240).TimeTable[Station].Mean()},
+		// Terminal A has three arrivals for each arrival at B.
+		{testModel(t, &LineConfig{stations, sections}, 240), 15},
+	}
+
+	for i, test := range tests {
+		if mean := MeanHeadway(test.model); math.Abs(mean-test.mean) > 1e-9 {
+			t.Errorf("Test %d: expected mean headway %.2f, got %.2f", i, test.mean, mean)
+		}
+		S := simulate(test.model, FIFODispatcher{}, 1)
+		if observed := GetMeanTime(S, Point0); math.Abs(observed-test.mean) > 0.05*test.mean {
+			t.Errorf("Test %d: expected observed mean headway near %.2f, got %.2f", i, test.mean, observed)
+		}
+	}
+}
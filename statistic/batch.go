@@ -0,0 +1,108 @@
+package statistic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Confidence interval of estimated mean.
+type Interval struct {
+	Mean, HalfWidth float64
+	Batches, Size   int
+}
+
+// String returns information about interval.
+func (in Interval) String() string {
+	return fmt.Sprintf("%.2f ± %.2f (%d batches of %d)", in.Mean, in.HalfWidth, in.Batches, in.Size)
+}
+
+// BatchMeansOf returns means of consecutive non-overlapping batches of specified size.
+// Observations which don't fill last batch are dropped.
+func BatchMeansOf(values []float64, size int) []float64 {
+	if size < 1 {
+		return nil
+	}
+	means := make([]float64, 0, len(values)/size)
+	for i := 0; i+size <= len(values); i += size {
+		sum := 0.0
+		for _, v := range values[i : i+size] {
+			sum += v
+		}
+		means = append(means, sum/float64(size))
+	}
+	return means
+}
+
+// Autocorrelation returns lag-1 autocorrelation of sequence.
+func Autocorrelation(values []float64) float64 {
+	if len(values) < 2 {
+		return 0.0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	num, den := 0.0, 0.0
+	for i, v := range values {
+		den += (v - mean) * (v - mean)
+		if i > 0 {
+			num += (values[i-1] - mean) * (v - mean)
+		}
+	}
+	if den == 0 {
+		return 0.0
+	}
+	return num / den
+}
+
+// BatchMeans returns confidence interval of mean by specified number of fixed-size batches.
+func BatchMeans(values []float64, batches int, confidence float64) (Interval, error) {
+	if batches < 2 {
+		return Interval{}, errors.New(fmt.Sprintf("incorrect number of batches in BatchMeans: %d", batches))
+	}
+	if len(values) < batches {
+		return Interval{}, errors.New(fmt.Sprintf("not enough observations in BatchMeans: %d", len(values)))
+	}
+	return interval(BatchMeansOf(values, len(values)/batches), len(values)/batches, confidence)
+}
+
+// AutoBatchMeans returns confidence interval of mean with automatically sized batches.
+// Batch size is doubled until absolute lag-1 autocorrelation of batch means is below threshold
+// or next doubling leaves less than minBatches batches.
+func AutoBatchMeans(values []float64, minBatches int, threshold, confidence float64) (Interval, error) {
+	if minBatches < 2 {
+		return Interval{}, errors.New(fmt.Sprintf("incorrect number of batches in AutoBatchMeans: %d", minBatches))
+	}
+	if len(values) < minBatches {
+		return Interval{}, errors.New(fmt.Sprintf("not enough observations in AutoBatchMeans: %d", len(values)))
+	}
+	size := 1
+	means := BatchMeansOf(values, size)
+	for math.Abs(Autocorrelation(means)) >= threshold && len(values)/(size*2) >= minBatches {
+		size *= 2
+		means = BatchMeansOf(values, size)
+	}
+	return interval(means, size, confidence)
+}
+
+func interval(means []float64, size int, confidence float64) (Interval, error) {
+	k := len(means)
+	mean := 0.0
+	for _, m := range means {
+		mean += m
+	}
+	mean /= float64(k)
+	variance := 0.0
+	for _, m := range means {
+		variance += (m - mean) * (m - mean)
+	}
+	variance /= float64(k - 1)
+
+	t, err := StudentQuantile(1-(1-confidence)/2, k-1)
+	if err != nil {
+		return Interval{}, err
+	}
+	return Interval{mean, t * math.Sqrt(variance/float64(k)), k, size}, nil
+}
@@ -0,0 +1,86 @@
+package statistic
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBatchMeansOf(t *testing.T) {
+	values := []float64{1, 3, 2, 4, 6, 8, 5}
+	expected := []float64{2, 3, 7}
+
+	means := BatchMeansOf(values, 2)
+	if len(means) != len(expected) {
+		t.Fatalf("Expected %d batches, got %d", len(expected), len(means))
+	}
+	for i, m := range means {
+		if m != expected[i] {
+			t.Errorf("Expected mean %.3f of batch %d, got %.3f", expected[i], i, m)
+		}
+	}
+}
+
+func TestAutocorrelation(t *testing.T) {
+	tests := []testPair{
+		{&Unit{values: []float64{1, 1, 1, 1}}, 0.0},
+		{&Unit{values: []float64{1, -1, 1, -1}}, -0.75},
+		{&Unit{values: []float64{1}}, 0.0},
+	}
+
+	for _, test := range tests {
+		if r := Autocorrelation(test.unit.Values()); math.Abs(r-test.result) > 1e-9 {
+			t.Errorf("Expected %.3f, got %.3f", test.result, r)
+		}
+	}
+}
+
+func TestStudentQuantile(t *testing.T) {
+	tests := []struct {
+		p      float64
+		df     int
+		result float64
+	}{
+		{0.975, 1, 12.7062},
+		{0.975, 2, 4.3027},
+		{0.975, 3, 3.1824},
+		{0.975, 5, 2.5706},
+		{0.975, 10, 2.2281},
+		{0.975, 20, 2.0860},
+		{0.975, 30, 2.0423},
+		{0.975, 1000, 1.9623},
+		{0.995, 2, 9.9248},
+		{0.95, 4, 2.1318},
+		{0.5, 3, 0},
+		{0.025, 1, -12.7062},
+	}
+
+	for _, test := range tests {
+		if r, err := StudentQuantile(test.p, test.df); err != nil || math.Abs(r-test.result) > 1e-4 {
+			t.Errorf("Expected %.4f quantile %.4f for %d degrees of freedom, got %.4f (%v)", test.p, test.result, test.df, r, err)
+		}
+	}
+	if _, err := StudentQuantile(0.975, 0); err == nil {
+		t.Errorf("Expected error for zero degrees of freedom")
+	}
+}
+
+func TestAutoBatchMeans(t *testing.T) {
+	values := make([]float64, 1024)
+	for i := range values {
+		values[i] = float64(i / 4 % 2)
+	}
+
+	in, err := AutoBatchMeans(values, 8, 0.1, 0.95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Mean != 0.5 {
+		t.Errorf("Expected mean %.3f, got %.3f", 0.5, in.Mean)
+	}
+	if in.Size != 2 || in.Batches != 512 {
+		t.Errorf("Expected 512 batches of 2, got %d of %d", in.Batches, in.Size)
+	}
+	if _, err := BatchMeans(values, 1, 0.95); err == nil {
+		t.Errorf("Expected error for single batch")
+	}
+}
@@ -0,0 +1,60 @@
+package statistic
+
+import (
+	"math"
+	"sort"
+)
+
+// KolmogorovSmirnov returns Kolmogorov-Smirnov statistic of values against cumulative distribution function.
+func KolmogorovSmirnov(values []float64, cdf func(float64) float64) float64 {
+	sorted := sortedCopy(values)
+	n := float64(len(sorted))
+	d := 0.0
+	for i, x := range sorted {
+		f := cdf(x)
+		d = math.Max(d, math.Max(float64(i+1)/n-f, f-float64(i)/n))
+	}
+	return d
+}
+
+// AndersonDarling returns Anderson-Darling statistic of values against cumulative distribution function.
+func AndersonDarling(values []float64, cdf func(float64) float64) float64 {
+	sorted := sortedCopy(values)
+	n := len(sorted)
+	clamp := func(f float64) float64 { return math.Min(math.Max(f, 1e-12), 1-1e-12) }
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		sum += float64(2*i+1) * (math.Log(clamp(cdf(sorted[i]))) + math.Log(1-clamp(cdf(sorted[n-1-i]))))
+	}
+	return -float64(n) - sum/float64(n)
+}
+
+// ChiSquare returns chi-square statistic of values against cumulative distribution function
+// by specified number of equiprobable bins.
+func ChiSquare(values []float64, cdf func(float64) float64, bins int) float64 {
+	if bins < 1 || len(values) == 0 {
+		return math.Inf(1)
+	}
+	observed := make([]int, bins)
+	for _, x := range values {
+		bin := int(cdf(x) * float64(bins))
+		if bin >= bins {
+			bin = bins - 1
+		} else if bin < 0 {
+			bin = 0
+		}
+		observed[bin]++
+	}
+	expected := float64(len(values)) / float64(bins)
+	sum := 0.0
+	for _, o := range observed {
+		sum += (float64(o) - expected) * (float64(o) - expected) / expected
+	}
+	return sum
+}
+
+func sortedCopy(values []float64) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted
+}
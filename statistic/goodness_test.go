@@ -0,0 +1,41 @@
+package statistic
+
+import (
+	"math"
+	"testing"
+)
+
+func uniformCDF(x float64) float64 {
+	return math.Min(math.Max(x, 0), 1)
+}
+
+func TestKolmogorovSmirnov(t *testing.T) {
+	tests := []struct {
+		values []float64
+		result float64
+	}{
+		{[]float64{0.125, 0.375, 0.625, 0.875}, 0.125},
+		{[]float64{0.9, 0.1}, 0.4},
+	}
+
+	for _, test := range tests {
+		if r := KolmogorovSmirnov(test.values, uniformCDF); math.Abs(r-test.result) > 1e-9 {
+			t.Errorf("Expected %.3f, got %.3f", test.result, r)
+		}
+	}
+}
+
+func TestGoodnessOrder(t *testing.T) {
+	good := []float64{0.1, 0.3, 0.5, 0.7, 0.9}
+	bad := []float64{0.01, 0.02, 0.03, 0.04, 0.05}
+
+	if AndersonDarling(good, uniformCDF) >= AndersonDarling(bad, uniformCDF) {
+		t.Errorf("Expected Anderson-Darling statistic of good fit less than of bad fit")
+	}
+	if r := ChiSquare(good, uniformCDF, 5); r != 0 {
+		t.Errorf("Expected %.3f, got %.3f", 0.0, r)
+	}
+	if r := ChiSquare(bad, uniformCDF, 5); r != 20 {
+		t.Errorf("Expected %.3f, got %.3f", 20.0, r)
+	}
+}
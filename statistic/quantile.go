@@ -0,0 +1,142 @@
+package statistic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// NormalQuantile returns quantile of standard normal distribution for specified probability.
+func NormalQuantile(p float64) (float64, error) {
+	if p <= 0 || p >= 1 {
+		return 0.0, errors.New(fmt.Sprintf("incorrect probability in NormalQuantile: %f", p))
+	}
+	// Rational approximation by P. J. Acklam, relative error less than 1.15e-9.
+	a := []float64{-3.969683028665376e+01, 2.209460984245205e+02, -2.759285104469687e+02,
+		1.383577518672690e+02, -3.066479806614716e+01, 2.506628277459239e+00}
+	b := []float64{-5.447609879822406e+01, 1.615858368580409e+02, -1.556989798598866e+02,
+		6.680131188771972e+01, -1.328068155288572e+01}
+	c := []float64{-7.784894002430293e-03, -3.223964580411365e-01, -2.400758277161838e+00,
+		-2.549732539343734e+00, 4.374664141464968e+00, 2.938163982698783e+00}
+	d := []float64{7.784695709041462e-03, 3.224671290700398e-01, 2.445134137142996e+00,
+		3.754408661907416e+00}
+	low, high := 0.02425, 1-0.02425
+
+	switch {
+	case p < low:
+		q := math.Sqrt(-2 * math.Log(p))
+		return (((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) /
+			((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1), nil
+	case p > high:
+		q := math.Sqrt(-2 * math.Log(1-p))
+		return -(((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) /
+			((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1), nil
+	default:
+		q := p - 0.5
+		r := q * q
+		return (((((a[0]*r+a[1])*r+a[2])*r+a[3])*r+a[4])*r + a[5]) * q /
+			(((((b[0]*r+b[1])*r+b[2])*r+b[3])*r+b[4])*r + 1), nil
+	}
+}
+
+// StudentQuantile returns quantile of Student's t-distribution for specified probability and degrees of freedom.
+// Quantile is found by inversion of exact distribution function for up to 30 degrees of freedom,
+// Cornish-Fisher expansion is precise enough for greater ones.
+func StudentQuantile(p float64, df int) (float64, error) {
+	if df < 1 {
+		return 0.0, errors.New(fmt.Sprintf("incorrect degrees of freedom in StudentQuantile: %d", df))
+	}
+	z, err := NormalQuantile(p)
+	if err != nil {
+		return 0.0, err
+	}
+	if df <= 30 {
+		// Distribution function is monotone, quantile is bracketed by doubling and found by bisection.
+		low, high := -1.0, 1.0
+		for StudentCDF(low, df) > p {
+			low *= 2
+		}
+		for StudentCDF(high, df) < p {
+			high *= 2
+		}
+		for i := 0; i < 200 && high-low > 1e-12*math.Max(1, math.Abs(low)); i++ {
+			if middle := (low + high) / 2; StudentCDF(middle, df) < p {
+				low = middle
+			} else {
+				high = middle
+			}
+		}
+		return (low + high) / 2, nil
+	}
+	// Cornish-Fisher expansion (Abramowitz and Stegun, 26.7.5).
+	n := float64(df)
+	z3, z5, z7, z9 := math.Pow(z, 3), math.Pow(z, 5), math.Pow(z, 7), math.Pow(z, 9)
+	g1 := (z3 + z) / 4
+	g2 := (5*z5 + 16*z3 + 3*z) / 96
+	g3 := (3*z7 + 19*z5 + 17*z3 - 15*z) / 384
+	g4 := (79*z9 + 776*z7 + 1482*z5 - 1920*z3 - 945*z) / 92160
+	return z + g1/n + g2/(n*n) + g3/(n*n*n) + g4/(n*n*n*n), nil
+}
+
+// StudentCDF returns distribution function of Student's t-distribution with specified degrees of freedom.
+func StudentCDF(t float64, df int) float64 {
+	n := float64(df)
+	tail := IncompleteBeta(n/(n+t*t), n/2, 0.5) / 2
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// IncompleteBeta returns regularized incomplete beta function I_x(a, b).
+func IncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0.0
+	}
+	if x >= 1 {
+		return 1.0
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// Continued fraction converges fast below mean, symmetry is used above it.
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaFraction(1-x, b, a)/b
+	}
+	return front * betaFraction(x, a, b) / a
+}
+
+// betaFraction evaluates continued fraction of incomplete beta function by modified Lentz's method.
+func betaFraction(x, a, b float64) float64 {
+	const tiny, epsilon = 1e-300, 1e-15
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	result := d
+	for m := 1; m <= 300; m++ {
+		k := float64(m)
+		// Even and odd steps of fraction.
+		for _, numerator := range []float64{
+			k * (b - k) * x / ((a + 2*k - 1) * (a + 2*k)),
+			-(a + k) * (a + b + k) * x / ((a + 2*k) * (a + 2*k + 1)),
+		} {
+			d = 1 + numerator*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + numerator/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			result *= d * c
+		}
+		if math.Abs(d*c-1) < epsilon {
+			break
+		}
+	}
+	return result
+}
@@ -1,20 +1,41 @@
 // Package statistic implements small unit for gathering mean and summary value.
 package statistic
 
-// Statistic unit.
+// Statistic unit. Unit keeps added values for batch means and quantiles only after Keep is called,
+// otherwise its memory doesn't grow with number of values.
 type Unit struct {
-	sum   float64
-	count int
+	sum    float64
+	count  int
+	keep   bool
+	values []float64
 }
 
 func new(sum float64, count int) *Unit {
-	return &Unit{sum, count}
+	return &Unit{sum, count, false, nil}
+}
+
+// NewUnit returns unit with summary value, number of values and kept values.
+func NewUnit(sum float64, count int, keep bool, values []float64) Unit {
+	return Unit{sum, count, keep, append([]float64(nil), values...)}
+}
+
+// Keep makes unit keep values added after call.
+func (u *Unit) Keep() {
+	u.keep = true
+}
+
+// Kept returns true if unit keeps added values.
+func (u *Unit) Kept() bool {
+	return u.keep
 }
 
 // AddValue adds new value of unit.
 func (u *Unit) AddValue(v float64) {
 	u.sum += v
 	u.count++
+	if u.keep {
+		u.values = append(u.values, v)
+	}
 }
 
 // Mean returns mean value.
@@ -30,3 +51,13 @@ func (u *Unit) Mean() float64 {
 func (u *Unit) Sum() float64 {
 	return u.sum
 }
+
+// Count returns number of added values.
+func (u *Unit) Count() int {
+	return u.count
+}
+
+// Values returns copy of sequence of kept values in order of addition.
+func (u *Unit) Values() []float64 {
+	return append([]float64(nil), u.values...)
+}
@@ -36,3 +36,24 @@ func TestSum(t *testing.T) {
 		}
 	}
 }
+
+func TestValues(t *testing.T) {
+	var u Unit
+	u.AddValue(1)
+	u.Keep()
+	u.AddValue(2)
+	u.AddValue(3)
+	values := u.Values()
+	if len(values) != 2 || values[0] != 2 || values[1] != 3 || u.Count() != 3 || u.Sum() != 6 {
+		t.Fatalf("Expected values kept after Keep [2 3] of 3 values with sum 6, got %v of %d with sum %.1f", values, u.Count(), u.Sum())
+	}
+	values[0] = 10
+	if u.Values()[0] != 2 {
+		t.Errorf("Expected copy of values, unit is changed by caller")
+	}
+	var skipped Unit
+	skipped.AddValue(1)
+	if skipped.Values() != nil || skipped.Mean() != 1 {
+		t.Errorf("Expected no values and mean 1 of unit without Keep, got %v and %.1f", skipped.Values(), skipped.Mean())
+	}
+}
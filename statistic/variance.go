@@ -0,0 +1,103 @@
+package statistic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Estimate of mean by independent observations.
+// Variance is variance of estimator, Reduction is achieved variance reduction factor
+// in comparison with crude estimator by the same number of runs.
+type Estimate struct {
+	Mean, Variance, Reduction float64
+	Count                     int
+}
+
+// HalfWidth returns half-width of confidence interval of estimate.
+func (e Estimate) HalfWidth(confidence float64) float64 {
+	t, err := StudentQuantile(1-(1-confidence)/2, e.Count-1)
+	if err != nil {
+		return math.NaN()
+	}
+	return t * math.Sqrt(e.Variance)
+}
+
+// Mean returns mean of values.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Variance returns unbiased sample variance of values.
+func Variance(values []float64) float64 {
+	return Covariance(values, values)
+}
+
+// Covariance returns unbiased sample covariance of two sequences of equal length.
+func Covariance(x, y []float64) float64 {
+	if len(x) < 2 || len(x) != len(y) {
+		return 0.0
+	}
+	mx, my := Mean(x), Mean(y)
+	sum := 0.0
+	for i := range x {
+		sum += (x[i] - mx) * (y[i] - my)
+	}
+	return sum / float64(len(x)-1)
+}
+
+// CrudeMean returns estimate of mean by independent replications.
+func CrudeMean(y []float64) (Estimate, error) {
+	if len(y) < 2 {
+		return Estimate{}, errors.New(fmt.Sprintf("not enough replications in CrudeMean: %d", len(y)))
+	}
+	return Estimate{Mean(y), Variance(y) / float64(len(y)), 1.0, len(y)}, nil
+}
+
+// AntitheticMean returns estimate of mean by replications paired with antithetic variates.
+// Replications 2i and 2i+1 form a pair.
+func AntitheticMean(y []float64) (Estimate, error) {
+	if len(y) < 4 || len(y)%2 != 0 {
+		return Estimate{}, errors.New(fmt.Sprintf("incorrect number of replications in AntitheticMean: %d", len(y)))
+	}
+	pairs := PairMeans(y)
+	variance := Variance(pairs) / float64(len(pairs))
+	return Estimate{Mean(pairs), variance, reduction(Variance(y)/float64(len(y)), variance), len(pairs)}, nil
+}
+
+// PairMeans returns means of consecutive pairs of values.
+func PairMeans(values []float64) []float64 {
+	return BatchMeansOf(values, 2)
+}
+
+// ControlVariateMean returns estimate of mean of y controlled by x with known mean mu.
+func ControlVariateMean(y, x []float64, mu float64) (Estimate, error) {
+	if len(y) < 3 || len(y) != len(x) {
+		return Estimate{}, errors.New(fmt.Sprintf("incorrect number of replications in ControlVariateMean: %d", len(y)))
+	}
+	c := 0.0
+	if vx := Variance(x); vx != 0 {
+		c = Covariance(x, y) / vx
+	}
+	controlled := make([]float64, len(y))
+	for i := range y {
+		controlled[i] = y[i] - c*(x[i]-mu)
+	}
+	// One degree of freedom is spent on estimation of coefficient.
+	variance := Variance(controlled) * float64(len(y)-1) / float64(len(y)-2) / float64(len(y))
+	return Estimate{Mean(controlled), variance, reduction(Variance(y)/float64(len(y)), variance), len(y) - 1}, nil
+}
+
+func reduction(crude, reduced float64) float64 {
+	if reduced == 0 {
+		return math.Inf(1)
+	}
+	return crude / reduced
+}
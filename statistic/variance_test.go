@@ -0,0 +1,37 @@
+package statistic
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAntitheticMean(t *testing.T) {
+	y := []float64{1, 3, 0, 4, 2, 2}
+
+	e, err := AntitheticMean(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Mean != 2 || e.Variance != 0 || e.Count != 3 {
+		t.Errorf("Expected estimate 2.000 with zero variance by 3 pairs, got %.3f, %.3f by %d", e.Mean, e.Variance, e.Count)
+	}
+	if _, err := AntitheticMean(y[:5]); err == nil {
+		t.Errorf("Expected error for odd number of replications")
+	}
+}
+
+func TestControlVariateMean(t *testing.T) {
+	x := []float64{44, 46, 45, 47, 43}
+	y := []float64{2*44 + 1, 2*46 - 1, 2 * 45, 2*47 + 1, 2*43 - 1}
+
+	e, err := ControlVariateMean(y, x, 45)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(e.Mean-90) > 1e-9 {
+		t.Errorf("Expected estimate %.3f, got %.3f", 90.0, e.Mean)
+	}
+	if e.Reduction <= 1 {
+		t.Errorf("Expected variance reduction, got factor %.3f", e.Reduction)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"simulation-modeling/sim"
+	"simulation-modeling/statistic"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Punctuality thresholds in minutes.
+var PunctualityLimits = []float64{3, 5, 10}
+
+// Scheduled stop of train at station by index of station in model.
+// Arrival at origin and departure from destination are not scheduled and set to -1.
+type Stop struct {
+	Station            int
+	Arrival, Departure float64
+}
+
+// Planned train with stops ordered along its route.
+type PlannedTrain struct {
+	Name     string
+	Priority int
+	Stops    []Stop
+}
+
+// Actual run of planned train in simulation.
+type Run struct {
+	Train                *PlannedTrain
+	Ready                float64
+	Arrivals, Departures map[int]float64
+}
+
+// Timetable of planned trains with delay statistics of their runs.
+type Timetable struct {
+	Trains []*PlannedTrain
+	runs   map[int]*Run
+}
+
+// Delay statistics of station.
+type StationDelay struct {
+	Arrival, Departure, Secondary statistic.Unit
+}
+
+// ReadTimetable returns timetable from CSV file with train, station, arrival and departure in each line.
+// Times are in minutes or "HH:MM", arrival at origin and departure from destination are empty.
+// First line is skipped as header if its arrival and departure can't be parsed.
+func ReadTimetable(FileName string, M *Model) (*Timetable, error) {
+	file, err := os.Open(FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 4
+	trains := make(map[string]*PlannedTrain)
+	timetable := &Timetable{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		times := make([]float64, 2)
+		for i, value := range record[2:] {
+			if value = strings.TrimSpace(value); value == "" {
+				times[i] = -1
+			} else if times[i], err = ParseClock(value); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, errors.New(fmt.Sprintf("%s:%d: incorrect time: %s", FileName, line, err))
+		}
+		station, ok := M.StationByName(strings.TrimSpace(record[1]))
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("%s:%d: unknown station %q", FileName, line, record[1]))
+		}
+		name := strings.TrimSpace(record[0])
+		train, ok := trains[name]
+		if !ok {
+			train = &PlannedTrain{Name: name}
+			trains[name] = train
+			timetable.Trains = append(timetable.Trains, train)
+		}
+		train.Stops = append(train.Stops, Stop{station, times[0], times[1]})
+	}
+	for _, train := range timetable.Trains {
+		if err := train.validate(M); err != nil {
+			return nil, errors.New(fmt.Sprintf("%s: train %s: %s", FileName, train.Name, err))
+		}
+	}
+	sort.SliceStable(timetable.Trains, func(i, j int) bool {
+		return timetable.Trains[i].Stops[0].Departure < timetable.Trains[j].Stops[0].Departure
+	})
+	return timetable, nil
+}
+
+func (T *PlannedTrain) validate(M *Model) error {
+	n := len(T.Stops)
+	if n < 2 {
+		return errors.New("at least origin and destination must be scheduled")
+	}
+	origin, destination := T.Stops[0], T.Stops[n-1]
+	if !M.Stations[origin.Station].Terminal || !M.Stations[destination.Station].Terminal {
+		return errors.New("origin and destination must be terminal stations")
+	}
+	if origin.Arrival >= 0 || origin.Departure < 0 || destination.Arrival < 0 || destination.Departure >= 0 {
+		return errors.New("departure from origin and arrival at destination only must be scheduled on terminals")
+	}
+	direction := 1
+	if destination.Station < origin.Station {
+		direction = -1
+	}
+	last := origin.Departure
+	for i, stop := range T.Stops[1:] {
+		if (stop.Station-T.Stops[i].Station)*direction <= 0 {
+			return errors.New(fmt.Sprintf("station %s is out of route order", M.Stations[stop.Station].Name))
+		}
+		if i+2 < n && (stop.Arrival < 0 || stop.Departure < stop.Arrival) {
+			return errors.New(fmt.Sprintf("arrival and departure must be scheduled on station %s", M.Stations[stop.Station].Name))
+		}
+		if stop.Arrival < last {
+			return errors.New(fmt.Sprintf("arrival on station %s is before previous departure", M.Stations[stop.Station].Name))
+		}
+		last = stop.Departure
+	}
+	return nil
+}
+
+// Stations returns set of origin points of timetable.
+func (T *Timetable) Stations(M *Model) map[int]bool {
+	stations := make(map[int]bool)
+	for _, train := range T.Trains {
+		stations[M.Stations[train.Stops[0].Station].Tracks[0]] = true
+	}
+	return stations
+}
+
+// Generate creates transactions of planned trains at origins after scheduled departure and primary delay.
+// Length of trains is set by distribution if it's specified.
+func (T *Timetable) Generate(S *sim.Sim, R sim.Source, M *Model, PrimaryDelay, Length sim.Distribution) {
+	T.runs = make(map[int]*Run, len(T.Trains))
+	for _, train := range T.Trains {
+		origin := train.Stops[0]
+		delay, err := PrimaryDelay.Sample(R)
+		if err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+		ready := origin.Departure + math.Max(0, delay)
+		tr, err := S.GeneratePriority(ready, M.Stations[origin.Station].Tracks[0], train.Priority)
+		if err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+		SetLength(S, R, tr, Length)
+		T.runs[sim.GetId(*tr)] = &Run{train, ready, map[int]float64{}, map[int]float64{}}
+	}
+}
+
+func (T *Timetable) stop(Run *Run, Station int) (Stop, bool) {
+	for _, stop := range Run.Train.Stops {
+		if stop.Station == Station {
+			return stop, true
+		}
+	}
+	return Stop{}, false
+}
+
+// Hold records arrival of planned train and delays its departure until scheduled time.
+// It returns true if transaction is returned to future event chain.
+func (T *Timetable) Hold(S *sim.Sim, M *Model, Tr *sim.Transaction) bool {
+	run, ok := T.runs[sim.GetId(*Tr)]
+	if !ok {
+		return false
+	}
+	points := sim.GetPoints(*Tr)
+	if _, ok := M.sectionIndex(points.Current); ok {
+		if station, ok := M.stationIndex(points.Next); ok {
+			run.Arrivals[station] = S.GetSimTime()
+		}
+		return false
+	}
+	station, ok := M.stationIndex(points.Current)
+	if _, next := M.sectionIndex(points.Next); !ok || !next {
+		return false
+	}
+	if stop, ok := T.stop(run, station); ok && stop.Departure > S.GetSimTime() {
+		if err := S.Delay(Tr, stop.Departure-S.GetSimTime()); err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		}
+		return true
+	}
+	return false
+}
+
+// Departed records departure of planned train from station to section.
+func (T *Timetable) Departed(S *sim.Sim, M *Model, Tr *sim.Transaction, P sim.Points) {
+	run, ok := T.runs[sim.GetId(*Tr)]
+	if !ok {
+		return
+	}
+	station, ok := M.stationIndex(P.Current)
+	if _, next := M.sectionIndex(P.Next); ok && next {
+		run.Departures[station] = S.GetSimTime()
+	}
+}
+
+// Delays returns delay statistics of completed runs by stations and number of completed runs.
+// Secondary delay is time between moment when train could depart by timetable and actual departure.
+func (T *Timetable) Delays(M *Model) ([]StationDelay, int) {
+	delays := make([]StationDelay, len(M.Stations))
+	for i := range delays {
+		delays[i].Arrival.Keep()
+		delays[i].Departure.Keep()
+	}
+	completed := 0
+	for _, run := range T.runs {
+		stops := run.Train.Stops
+		if _, ok := run.Arrivals[stops[len(stops)-1].Station]; !ok {
+			continue
+		}
+		completed++
+		for i, stop := range stops {
+			arrival, arrived := run.Arrivals[stop.Station]
+			departure, departed := run.Departures[stop.Station]
+			if i == 0 {
+				arrival, arrived = run.Ready, false
+			}
+			if arrived && stop.Arrival >= 0 {
+				delays[stop.Station].Arrival.AddValue(arrival - stop.Arrival)
+			}
+			if departed && stop.Departure >= 0 {
+				delays[stop.Station].Departure.AddValue(departure - stop.Departure)
+				delays[stop.Station].Secondary.AddValue(departure - math.Max(stop.Departure, arrival))
+			}
+		}
+	}
+	return delays, completed
+}
+
+// Punctuality returns shares of delays not greater than punctuality limits.
+func Punctuality(Delays []float64) []float64 {
+	shares := make([]float64, len(PunctualityLimits))
+	for i, limit := range PunctualityLimits {
+		for _, delay := range Delays {
+			if delay <= limit {
+				shares[i]++
+			}
+		}
+		if len(Delays) != 0 {
+			shares[i] /= float64(len(Delays))
+		}
+	}
+	return shares
+}
+
+func WriteTimetableReport(Writer *bufio.Writer, T *Timetable, M *Model) {
+	delays, completed := T.Delays(M)
+	limits := make([]string, len(PunctualityLimits))
+	for i, limit := range PunctualityLimits {
+		limits[i] = strconv.FormatFloat(limit, 'f', -1, 64)
+	}
+	punctuality := func(unit statistic.Unit) string {
+		shares := make([]string, len(PunctualityLimits))
+		for i, share := range Punctuality(unit.Values()) {
+			shares[i] = fmt.Sprintf("%.1f%%", share*100)
+		}
+		return fmt.Sprintf("mean %.2f, within %s min: %s", unit.Mean(), strings.Join(limits, "/"), strings.Join(shares, "/"))
+	}
+	WriteData(Writer, fmt.Sprintf("Timetable: %d trains, %d completed\n", len(T.Trains), completed))
+	for i, station := range M.Stations {
+		if delay := delays[i].Arrival; len(delay.Values()) != 0 {
+			WriteData(Writer, fmt.Sprintf("Arrival delay on %s: %s\n", StationLabel(station), punctuality(delay)))
+		}
+		if delay := delays[i].Departure; len(delay.Values()) != 0 {
+			WriteData(Writer, fmt.Sprintf("Departure delay on %s: %s\n", StationLabel(station), punctuality(delay)))
+		}
+		if delay := delays[i].Secondary; delay.Count() != 0 {
+			WriteData(Writer, fmt.Sprintf("Secondary delay on %s: mean %.2f, total %.2f\n", StationLabel(station), delay.Mean(), delay.Sum()))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"simulation-modeling/sim"
+	"strings"
+	"testing"
+)
+
+// testTimetable writes timetable to temporary file and reads it for model.
+func testTimetable(t *testing.T, M *Model, Lines string) (*Timetable, error) {
+	file := filepath.Join(t.TempDir(), "timetable.csv")
+	if err := os.WriteFile(file, []byte(Lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ReadTimetable(file, M)
+}
+
+func TestReadTimetable(t *testing.T) {
+	M := testModel(t, nil, 24)
+	tests := []struct {
+		lines string
+		valid bool
+	}{
+		{"train,station,arrival,departure\nT1,A,,10:00\nT1,C,10:20,10:30\nT1,B,10:55,\n", true},
+		{"T1,B,,60\nT1,A,90,\n", true},
+		{"T1,A,,10:00\nT1,X,10:55,\n", false},
+		{"T1,A,,10:00\n", false},
+		{"T1,C,,10:00\nT1,B,10:55,\n", false},
+		{"T1,A,,10:00\nT1,C,10:20,\nT1,B,10:55,\n", false},
+		{"T1,A,,10:00\nT1,C,9:20,10:30\nT1,B,10:55,\n", false},
+		{"T1,A,,10:00\nT1,B,10:55,\nT1,C,10:20,10:30\n", false},
+		{"T1,A,,10:00\nT1,B,10:xx,\n", false},
+	}
+
+	for i, test := range tests {
+		timetable, err := testTimetable(t, M, test.lines)
+		if test.valid && err != nil {
+			t.Errorf("Test %d: unexpected error %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Test %d: expected error of timetable %+v", i, timetable.Trains[0])
+		}
+	}
+
+	timetable, _ := testTimetable(t, M, "T2,A,,120\nT2,B,150,\nT1,A,,60\nT1,C,70,80\nT1,B,100,\n")
+	expected := []*PlannedTrain{
+		{"T1", 0, []Stop{{0, -1, 60}, {1, 70, 80}, {2, 100, -1}}},
+		{"T2", 0, []Stop{{0, -1, 120}, {2, 150, -1}}},
+	}
+	if !reflect.DeepEqual(timetable.Trains, expected) {
+		t.Errorf("Expected trains ordered by departure %v, got %v", expected, timetable.Trains)
+	}
+}
+
+func TestTimetableRun(t *testing.T) {
+	M := testModel(t, nil, 6)
+	M.TimeTable[Delay] = sim.Pair{0, 0}
+	timetable, err := testTimetable(t, M, "T1,A,,60\nT1,C,70,90\nT1,B,100,\nT2,B,,120\nT2,C,145,150\nT2,A,170,\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	M.Timetable = timetable
+	M.RoadMap = TraceRoadMap(M.RoadMap, timetable.Stations(M))
+	simulate(M, FIFODispatcher{}, 1)
+
+	delays, completed := timetable.Delays(M)
+	if completed != 2 {
+		t.Fatalf("Expected 2 completed runs, got %d", completed)
+	}
+	// Trains run through empty line, they are held at loop C until scheduled departure.
+	for _, run := range timetable.runs {
+		departure := run.Departures[1]
+		if stop := run.Train.Stops[1]; departure != stop.Departure {
+			t.Errorf("Expected departure of train %s from C at %.0f, got %.2f", run.Train.Name, stop.Departure, departure)
+		}
+	}
+	if delay := delays[1].Departure; len(delay.Values()) != 2 || delay.Mean() != 0 {
+		t.Errorf("Expected 2 departures from C without delay, got %v", delay.Values())
+	}
+	if delay := delays[0].Departure; len(delay.Values()) != 1 || delay.Mean() != 0 {
+		t.Errorf("Expected departure from A without delay, got %v", delay.Values())
+	}
+	// Secondary delay is counted without kept values, holding at loop isn't secondary delay.
+	if delay := delays[1].Secondary; delay.Count() != 2 || delay.Sum() != 0 {
+		t.Errorf("Expected 2 secondary delays on C without delay, got %d with sum %.2f", delay.Count(), delay.Sum())
+	}
+	var buffer bytes.Buffer
+	writer := bufio.NewWriter(&buffer)
+	WriteTimetableReport(writer, timetable, M)
+	writer.Flush()
+	for _, station := range M.Stations {
+		if line := "Secondary delay on " + StationLabel(station); !strings.Contains(buffer.String(), line) {
+			t.Errorf("Expected %q in report %q", line, buffer.String())
+		}
+	}
+}
+
+func TestPunctuality(t *testing.T) {
+	tests := []struct {
+		delays, shares []float64
+	}{
+		{[]float64{0, 4, 6, 12}, []float64{0.25, 0.5, 0.75}},
+		{[]float64{-1, 3, 5, 10}, []float64{0.5, 0.75, 1}},
+		{nil, []float64{0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		if shares := Punctuality(test.delays); !reflect.DeepEqual(shares, test.shares) {
+			t.Errorf("Expected shares %v of delays %v, got %v", test.shares, test.delays, shares)
+		}
+	}
+}
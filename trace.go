@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"simulation-modeling/sim"
+	"strconv"
+	"strings"
+)
+
+// Recorded arrival of train at origin station.
+// Priority and length are set by optional attributes "priority=N" and "length=L".
+type Arrival struct {
+	Time       float64
+	Point      int
+	Priority   int
+	Length     float64
+	Attributes []string
+}
+
+// ParseClock returns time in minutes by number of minutes or by "HH:MM" clock time.
+func ParseClock(Value string) (float64, error) {
+	if parts := strings.Split(Value, ":"); len(parts) == 2 {
+		hours, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0.0, err
+		}
+		minutes, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return 0.0, err
+		}
+		return float64(hours*60) + minutes, nil
+	}
+	return strconv.ParseFloat(Value, 64)
+}
+
+// ReadTrace returns arrivals from CSV file with time, origin and optional attributes in each line.
+// Origin is name of terminal station of model. First line is skipped as header if its time can't be parsed.
+func ReadTrace(FileName string, M *Model) ([]Arrival, error) {
+	file, err := os.Open(FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	trace := make([]Arrival, 0, 100)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, errors.New(fmt.Sprintf("%s:%d: expected time and origin", FileName, line))
+		}
+		time, err := ParseClock(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, errors.New(fmt.Sprintf("%s:%d: incorrect time %q", FileName, line, record[0]))
+		}
+		point, ok := M.Terminal(strings.TrimSpace(record[1]))
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("%s:%d: unknown origin %q", FileName, line, record[1]))
+		}
+		priority, length := 0, 0.0
+		for _, attribute := range record[2:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(attribute), "priority="); ok {
+				if priority, err = strconv.Atoi(value); err != nil {
+					return nil, errors.New(fmt.Sprintf("%s:%d: incorrect priority %q", FileName, line, value))
+				}
+			}
+			if value, ok := strings.CutPrefix(strings.TrimSpace(attribute), "length="); ok {
+				if length, err = strconv.ParseFloat(value, 64); err != nil || length < 0 {
+					return nil, errors.New(fmt.Sprintf("%s:%d: incorrect length %q", FileName, line, value))
+				}
+			}
+		}
+		trace = append(trace, Arrival{time, point, priority, length, record[2:]})
+	}
+	return trace, nil
+}
+
+// ValidateTrace checks that arrivals are ordered by time and
+// arrivals at the same station are separated by more than minimal headway.
+func ValidateTrace(Trace []Arrival, Headway float64) error {
+	last := make(map[int]float64)
+	for i, arrival := range Trace {
+		if arrival.Time < 0 {
+			return errors.New(fmt.Sprintf("arrival %d: negative time %.1f", i+1, arrival.Time))
+		}
+		if i > 0 && arrival.Time < Trace[i-1].Time {
+			return errors.New(fmt.Sprintf("arrival %d: time %.1f is before previous arrival %.1f", i+1, arrival.Time, Trace[i-1].Time))
+		}
+		if previous, ok := last[arrival.Point]; ok && arrival.Time-previous <= Headway {
+			return errors.New(fmt.Sprintf("arrival %d: overlaps previous arrival at %.1f on the same station", i+1, previous))
+		}
+		last[arrival.Point] = arrival.Time
+	}
+	return nil
+}
+
+// TraceStations returns points of terminal stations with trace-driven arrivals by their names,
+// by default they are all stations of recorded arrivals. Station without recorded arrivals can't be traced,
+// otherwise it would have no arrivals at all.
+func TraceStations(Trace []Arrival, Names []string, M *Model) (map[int]bool, error) {
+	recorded := make(map[int]bool)
+	for _, arrival := range Trace {
+		recorded[arrival.Point] = true
+	}
+	if len(Names) == 0 {
+		return recorded, nil
+	}
+	stations := make(map[int]bool)
+	for _, name := range Names {
+		point, ok := M.Terminal(strings.TrimSpace(name))
+		switch {
+		case !ok:
+			return nil, errors.New(fmt.Sprintf("unknown station in trace stations: %q", name))
+		case !recorded[point]:
+			return nil, errors.New(fmt.Sprintf("no recorded arrivals at trace station %q", name))
+		}
+		stations[point] = true
+	}
+	return stations, nil
+}
+
+// FilterTrace returns arrivals at specified stations.
+func FilterTrace(Trace []Arrival, Stations map[int]bool) []Arrival {
+	filtered := make([]Arrival, 0, len(Trace))
+	for _, arrival := range Trace {
+		if Stations[arrival.Point] {
+			filtered = append(filtered, arrival)
+		}
+	}
+	return filtered
+}
+
+// TraceRoadMap returns copy of road map without random generation of arrivals at traced stations.
+func TraceRoadMap(RoadMap map[Checks][]Action, Stations map[int]bool) map[Checks][]Action {
+	roadMap := make(map[Checks][]Action, len(RoadMap))
+	for checks, actions := range RoadMap {
+		roadMap[checks] = make([]Action, 0, len(actions))
+		for _, action := range actions {
+			if action.Type == Generate && Stations[action.Arguments[1]] {
+				continue
+			}
+			roadMap[checks] = append(roadMap[checks], action)
+		}
+	}
+	return roadMap
+}
+
+// GenerateTrace creates transactions by recorded arrivals and returns set of traced stations.
+func GenerateTrace(S *sim.Sim, Trace []Arrival) map[int]bool {
+	last := make(map[int]float64)
+	for _, arrival := range Trace {
+		if tr, err := S.GeneratePriority(arrival.Time, arrival.Point, arrival.Priority); err != nil {
+			fmt.Println(err, S.DebugString())
+			os.Exit(1)
+		} else {
+			tr.SetLength(arrival.Length)
+		}
+		S.AddStatistic(Point0, arrival.Time-last[arrival.Point])
+		last[arrival.Point] = arrival.Time
+	}
+	traced := make(map[int]bool)
+	for point := range last {
+		traced[point] = true
+	}
+	return traced
+}
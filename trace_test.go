@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testTrace writes trace to temporary file and reads it for model.
+func testTrace(t *testing.T, M *Model, Lines string) ([]Arrival, error) {
+	file := filepath.Join(t.TempDir(), "trace.csv")
+	if err := os.WriteFile(file, []byte(Lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ReadTrace(file, M)
+}
+
+func TestReadTrace(t *testing.T) {
+	M := testModel(t, nil, 24)
+	tests := []struct {
+		lines string
+		trace []Arrival
+		valid bool
+	}{
+		{"time,origin\n10:00,A\n", []Arrival{{600, PointA, 0, 0, []string{}}}, true},
+		{"5,b,priority=2, length=450\n", []Arrival{{5, PointB, 2, 450, []string{"priority=2", " length=450"}}}, true},
+		{"5,A,note\n7.5, B\n", []Arrival{{5, PointA, 0, 0, []string{"note"}}, {7.5, PointB, 0, 0, []string{}}}, true},
+		{"5\n", nil, false},
+		{"5,C\n", nil, false},
+		{"5,A\nxx,B\n", nil, false},
+		{"5,A,priority=x\n", nil, false},
+		{"5,A,length=-1\n", nil, false},
+	}
+
+	for i, test := range tests {
+		trace, err := testTrace(t, M, test.lines)
+		if test.valid && (err != nil || !reflect.DeepEqual(trace, test.trace)) {
+			t.Errorf("Test %d: expected trace %v, got %v (%v)", i, test.trace, trace, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Test %d: expected error of trace %v", i, trace)
+		}
+	}
+}
+
+func TestValidateTrace(t *testing.T) {
+	tests := []struct {
+		trace   []Arrival
+		headway float64
+		valid   bool
+	}{
+		{[]Arrival{{Time: 5, Point: PointA}, {Time: 5, Point: PointB}, {Time: 20, Point: PointA}}, 10, true},
+		{[]Arrival{{Time: 5, Point: PointA}, {Time: 15, Point: PointA}}, 10, false},
+		{[]Arrival{{Time: 5, Point: PointA}, {Time: 5, Point: PointA}}, 0, false},
+		{[]Arrival{{Time: 20, Point: PointA}, {Time: 5, Point: PointB}}, 0, false},
+		{[]Arrival{{Time: -1, Point: PointA}}, 0, false},
+		{nil, 0, true},
+	}
+
+	for i, test := range tests {
+		if err := ValidateTrace(test.trace, test.headway); (err == nil) != test.valid {
+			t.Errorf("Test %d: expected valid %t, got error %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestFilterTrace(t *testing.T) {
+	trace := []Arrival{{Time: 5, Point: PointA}, {Time: 6, Point: PointB}, {Time: 7, Point: PointA}}
+	tests := []struct {
+		stations map[int]bool
+		trace    []Arrival
+	}{
+		{map[int]bool{PointA: true, PointB: true}, trace},
+		{map[int]bool{PointA: true}, []Arrival{trace[0], trace[2]}},
+		{map[int]bool{PointA: false, PointB: true}, []Arrival{trace[1]}},
+		{map[int]bool{}, []Arrival{}},
+	}
+
+	for i, test := range tests {
+		if filtered := FilterTrace(trace, test.stations); !reflect.DeepEqual(filtered, test.trace) {
+			t.Errorf("Test %d: expected %v, got %v", i, test.trace, filtered)
+		}
+	}
+}
+
+func TestTraceStations(t *testing.T) {
+	M := testModel(t, nil, 24)
+	trace := []Arrival{{Time: 5, Point: PointA}, {Time: 7, Point: PointA}}
+	tests := []struct {
+		names    []string
+		stations map[int]bool
+		valid    bool
+	}{
+		{nil, map[int]bool{PointA: true}, true},
+		{[]string{" a"}, map[int]bool{PointA: true}, true},
+		// Station without recorded arrivals would lose its random arrivals too.
+		{[]string{"A", "B"}, nil, false},
+		{[]string{"C"}, nil, false},
+	}
+
+	for i, test := range tests {
+		stations, err := TraceStations(trace, test.names, M)
+		if (err == nil) != test.valid || !reflect.DeepEqual(stations, test.stations) {
+			t.Errorf("Test %d: expected stations %v and valid %t, got %v (%v)", i, test.stations, test.valid, stations, err)
+		}
+	}
+}
+
+func TestTraceRoadMap(t *testing.T) {
+	M := testModel(t, nil, 24)
+	tests := []struct {
+		stations map[int]bool
+		a, b     int
+	}{
+		{map[int]bool{}, 2, 2},
+		{map[int]bool{PointA: true}, 0, 2},
+		{map[int]bool{PointA: true, PointB: true}, 0, 0},
+	}
+
+	generates := func(roadMap map[Checks][]Action, point int) int {
+		count := 0
+		for _, pass := range []bool{false, true} {
+			for _, action := range roadMap[Checks{Point0, point, pass}] {
+				if action.Type == Generate {
+					count++
+				}
+			}
+		}
+		return count
+	}
+	for i, test := range tests {
+		roadMap := TraceRoadMap(M.RoadMap, test.stations)
+		// Generation is removed for both outcomes of checks.
+		if a, b := generates(roadMap, PointA), generates(roadMap, PointB); a != test.a || b != test.b {
+			t.Errorf("Test %d: expected %d and %d generations at A and B, got %d and %d", i, test.a, test.b, a, b)
+		}
+		if actions := roadMap[Checks{Point0, PointA, true}]; len(roadMap) != len(M.RoadMap) || actions[0].Type != Use {
+			t.Errorf("Test %d: expected other actions kept, got %v", i, actions)
+		}
+	}
+	if generates(M.RoadMap, PointA) != 2 {
+		t.Errorf("Expected road map of model unchanged")
+	}
+}
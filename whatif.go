@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"simulation-modeling/sim"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Intervention of what-if branch: section is closed for duration in minutes or dispatching policy is changed.
+type Intervention struct {
+	Kind     string
+	Section  int
+	Duration float64
+	Policy   string
+}
+
+// ParseIntervention returns intervention from text "close SECTION MINUTES" or "dispatch POLICY".
+func ParseIntervention(Text string, M *Model) (Intervention, error) {
+	fields := strings.Fields(Text)
+	switch {
+	case len(fields) == 3 && fields[0] == "close":
+		for i, section := range M.Sections {
+			if strings.EqualFold(section.Name, fields[1]) {
+				duration, err := strconv.ParseFloat(fields[2], 64)
+				if err != nil || duration <= 0 {
+					return Intervention{}, errors.New(fmt.Sprintf("incorrect duration of closure: %q", fields[2]))
+				}
+				return Intervention{Kind: "close", Section: i, Duration: duration}, nil
+			}
+		}
+		return Intervention{}, errors.New(fmt.Sprintf("unknown section: %q", fields[1]))
+	case len(fields) == 2 && fields[0] == "dispatch":
+		if _, err := NewDispatcher(fields[1]); err != nil {
+			return Intervention{}, err
+		}
+		return Intervention{Kind: "dispatch", Policy: fields[1]}, nil
+	}
+	return Intervention{}, errors.New(fmt.Sprintf("incorrect intervention %q, expected close SECTION MINUTES or dispatch POLICY", Text))
+}
+
+// Branch of what-if analysis: copy of simulation run continued from fork under interventions.
+// Closed point is seized as soon as it's free and isn't next waypoint of any train, so trains already
+// heading to it pass first. Helper transaction releases it after duration of closure.
+type Branch struct {
+	Name string
+	S    *sim.Sim
+	R    sim.Source
+	M    *Model
+	D    Dispatcher
+	Fork float64
+	// Summary and number of statistic values of points at fork.
+	sums    []float64
+	counts  []int
+	closing map[int]float64
+}
+
+// fork returns copy of model with own layers, road map and no charts, animation and checkpoints.
+func (M *Model) fork() *Model {
+	m := *M
+	m.RoadMap = make(map[Checks][]Action, len(M.RoadMap))
+	for checks, actions := range M.RoadMap {
+		m.RoadMap[checks] = actions
+	}
+	if M.Timetable != nil {
+		timetable := *M.Timetable
+		m.Timetable = &timetable
+	}
+	if M.Energy != nil {
+		energy := *M.Energy
+		m.Energy = &energy
+	}
+	if M.Fleet != nil {
+		fleet := *M.Fleet
+		m.Fleet = &fleet
+	}
+	if M.Passengers != nil {
+		passengers := *M.Passengers
+		m.Passengers = &passengers
+	}
+	m.Journal, m.Animation, m.Checkpoints = nil, nil, nil
+	return &m
+}
+
+// Fork returns branch which continues simulation run of model from checkpoint under interventions.
+func Fork(C *Checkpoint, M *Model, Name string, Interventions []Intervention) (*Branch, error) {
+	clone, err := C.Copy()
+	if err != nil {
+		return nil, err
+	}
+	B := &Branch{Name: Name, M: M.fork(), closing: make(map[int]float64)}
+	if B.D, err = NewDispatcher(C.Dispatch); err != nil {
+		return nil, err
+	}
+	if B.S, B.R, err = clone.Restore(B.M, B.D); err != nil {
+		return nil, err
+	}
+	B.Fork = B.S.GetSimTime()
+	B.sums, B.counts = B.statistic()
+	for _, intervention := range Interventions {
+		switch intervention.Kind {
+		case "close":
+			for _, point := range B.M.Sections[intervention.Section].Points {
+				B.closing[point] = intervention.Duration
+				B.M.RoadMap[Checks{Point0, point, true}] = []Action{Action{Release, []int{point}}}
+			}
+		case "dispatch":
+			B.D, _ = NewDispatcher(intervention.Policy)
+		}
+	}
+	return B, nil
+}
+
+// close seizes points waiting for closure when they are free.
+func (B *Branch) close() {
+	for point, duration := range B.closing {
+		if state, _ := B.S.GetPointState(point); state != sim.NUsed || Reserved(B.S, nil, point) {
+			continue
+		}
+		if err := B.S.SeizePoint(point); err != nil {
+			fmt.Println(err, B.S.DebugString())
+			os.Exit(1)
+		}
+		if err := B.S.Generate(duration, point); err != nil {
+			fmt.Println(err, B.S.DebugString())
+			os.Exit(1)
+		}
+		delete(B.closing, point)
+	}
+}
+
+// Run continues simulation of branch until its end.
+func (B *Branch) Run() {
+	for !B.S.IsFinish() {
+		B.close()
+		Phases(B.S, B.R, B.M, B.D)
+	}
+}
+
+// statistic returns summary and number of statistic values of points.
+func (B *Branch) statistic() ([]float64, []int) {
+	sums, counts := make([]float64, B.M.Points), make([]int, B.M.Points)
+	for p := range sums {
+		_, sum, err := B.S.GetStatistic(p)
+		if err == nil {
+			counts[p], err = B.S.GetCount(p)
+		}
+		if err != nil {
+			fmt.Println(err, B.S.DebugString())
+			os.Exit(1)
+		}
+		sums[p] = sum
+	}
+	return sums, counts
+}
+
+// Waiting returns mean waiting time on station after fork.
+func (B *Branch) Waiting(Station ModelStation) float64 {
+	sums, counts := B.statistic()
+	waitingTime := 0.0
+	for _, track := range Station.Tracks {
+		if count := counts[track] - B.counts[track]; count > 0 {
+			waitingTime += (sums[track] - B.sums[track]) / float64(count)
+		}
+	}
+	return waitingTime / float64(len(Station.Tracks))
+}
+
+// Utilization returns utilization ratio of section after fork.
+func (B *Branch) Utilization(Section ModelSection) float64 {
+	sums, _ := B.statistic()
+	sumTime := 0.0
+	for _, point := range Section.Points {
+		sumTime += sums[point] - B.sums[point]
+	}
+	if B.S.GetSimTime() <= B.Fork {
+		return 0
+	}
+	return sumTime / ((B.S.GetSimTime() - B.Fork) * float64(Section.Blocks))
+}
+
+// WriteWhatIfReport writes statistics of branches after fork side by side, one column for each branch.
+func WriteWhatIfReport(Writer *bufio.Writer, Branches []*Branch) {
+	M := Branches[0].M
+	WriteData(Writer, fmt.Sprintf("%s what-if analysis, fork at %.1f\n", M.Title, Branches[0].Fork))
+	row := func(name string, value func(B *Branch) float64) {
+		WriteData(Writer, fmt.Sprintf("%-36s", name))
+		for _, B := range Branches {
+			WriteData(Writer, fmt.Sprintf(" %24.2f", value(B)))
+		}
+		WriteData(Writer, "\n")
+	}
+	WriteData(Writer, fmt.Sprintf("%-36s", "Branch"))
+	for _, B := range Branches {
+		WriteData(Writer, fmt.Sprintf(" %24s", B.Name))
+	}
+	WriteData(Writer, "\n")
+	for _, station := range M.Stations {
+		station := station
+		row("Mean waiting time on "+StationLabel(station), func(B *Branch) float64 { return B.Waiting(station) })
+	}
+	for _, section := range M.Sections {
+		section := section
+		row(fmt.Sprintf("Utilization ratio for %s track", section.Name), func(B *Branch) float64 { return B.Utilization(section) })
+	}
+	row("Trains waiting at end", func(B *Branch) float64 { return float64(len(B.S.GetWaitlist())) })
+}
+
+// Branches of what-if analysis in command line, flag is repeated for each branch.
+type branchFlags []string
+
+func (F *branchFlags) String() string {
+	return strings.Join(*F, ", ")
+}
+
+func (F *branchFlags) Set(Value string) error {
+	*F = append(*F, Value)
+	return nil
+}
+
+// WhatIf forks simulation run at specified time or from checkpoint and compares branches under interventions.
+func WhatIf(Arguments []string) {
+	flags := flag.NewFlagSet("whatif", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s whatif [-c FILE] [-d DURATION] [-s SEED] [-dispatch POLICY] [-timetable FILE] -at T|-from FILE -branch INTERVENTIONS...\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	configFlag := flags.String("c", "", "read model configuration from file")
+	durationFlag := flags.Float64("d", 24, "set simulation duration in hours")
+	seedFlag := flags.Int64("s", 0, "set seed of random stream (default: current time)")
+	dispatchFlag := flags.String("dispatch", "fifo", "set dispatching policy: fifo, priority, alternate or lookahead")
+	timetableFlag := flags.String("timetable", "", "read planned trains from CSV file with train, station, arrival and departure")
+	atFlag := flags.String("at", "", "fork simulation run at time in minutes or HH:MM")
+	fromFlag := flags.String("from", "", "fork simulation run from checkpoint file")
+	var branches branchFlags
+	flags.Var(&branches, "branch", "add branch with interventions separated by semicolons: close SECTION MINUTES, dispatch POLICY")
+	flags.Parse(Arguments)
+	if (*atFlag == "") == (*fromFlag == "") || len(branches) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config := DefaultConfig()
+	if *configFlag != "" {
+		var err error
+		if config, err = LoadConfig(*configFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	model, err := config.Model(*durationFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	dispatcher, err := NewDispatcher(*dispatchFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *timetableFlag != "" {
+		if model.Timetable, err = ReadTimetable(*timetableFlag, model); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		model.RoadMap = TraceRoadMap(model.RoadMap, model.Timetable.Stations(model))
+	}
+	interventions := make([][]Intervention, len(branches))
+	for i, branch := range branches {
+		for _, text := range strings.Split(branch, ";") {
+			intervention, err := ParseIntervention(text, model)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			interventions[i] = append(interventions[i], intervention)
+		}
+	}
+
+	var checkpoint *Checkpoint
+	if *fromFlag != "" {
+		if checkpoint, err = LoadCheckpoint(*fromFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	} else {
+		at, err := ParseClock(*atFlag)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		seed := *seedFlag
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		R := NewStream(seed, 0, false)
+		S := Start(R, model, nil)
+		for future := S.GetFuture(); !S.IsFinish() && len(future) != 0 && sim.GetTime(*future[0]) <= at; future = S.GetFuture() {
+			Phases(S, R, model, dispatcher)
+		}
+		if checkpoint, err = NewCheckpoint(S, R, model, dispatcher); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	result := make([]*Branch, 0, len(branches)+1)
+	for i, name := range append([]string{"as is"}, branches...) {
+		var branch []Intervention
+		if i > 0 {
+			branch = interventions[i-1]
+		}
+		B, err := Fork(checkpoint, model, strings.TrimSpace(name), branch)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		B.Run()
+		result = append(result, B)
+	}
+	writer := bufio.NewWriter(os.Stdout)
+	WriteWhatIfReport(writer, result)
+	if err := writer.Flush(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
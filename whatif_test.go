@@ -0,0 +1,81 @@
+package main
+
+import (
+	"simulation-modeling/sim"
+	"testing"
+)
+
+func TestParseIntervention(t *testing.T) {
+	M := testModel(t, nil, 24)
+	tests := []struct {
+		text         string
+		intervention Intervention
+		valid        bool
+	}{
+		{"close ac 30", Intervention{Kind: "close", Section: 0, Duration: 30}, true},
+		{" close BC  5.5 ", Intervention{Kind: "close", Section: 1, Duration: 5.5}, true},
+		{"dispatch priority", Intervention{Kind: "dispatch", Policy: "priority"}, true},
+		{"close AC 0", Intervention{}, false},
+		{"close AC x", Intervention{}, false},
+		{"close XY 10", Intervention{}, false},
+		{"dispatch x", Intervention{}, false},
+		{"close AC", Intervention{}, false},
+		{"open AC 10", Intervention{}, false},
+	}
+
+	for i, test := range tests {
+		intervention, err := ParseIntervention(test.text, M)
+		if (err == nil) != test.valid || intervention != test.intervention {
+			t.Errorf("Test %d: expected intervention %+v and valid %t, got %+v (%v)", i, test.intervention, test.valid, intervention, err)
+		}
+	}
+}
+
+func TestFork(t *testing.T) {
+	M := testModel(t, nil, 24)
+	R := NewStream(1, 0, false)
+	S := Start(R, M, nil)
+	for future := S.GetFuture(); sim.GetTime(*future[0]) <= 360; future = S.GetFuture() {
+		Phases(S, R, M, FIFODispatcher{})
+	}
+	C, err := NewCheckpoint(S, R, M, FIFODispatcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closure, err := ParseIntervention("close AC 120", M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var branches []*Branch
+	for _, interventions := range [][]Intervention{nil, {closure}} {
+		B, err := Fork(C, M, "branch", interventions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		B.Run()
+		branches = append(branches, B)
+	}
+	Continue(S, R, M, FIFODispatcher{})
+
+	// Branch without interventions reproduces the run after fork, closure doesn't change model of run.
+	same, closed := branches[0].S, branches[1].S
+	if same.GetSimTime() != S.GetSimTime() || branches[0].Fork != C.Sim.SimTime {
+		t.Errorf("Expected branch from %.2f to end at %.2f, got from %.2f to %.2f", C.Sim.SimTime, S.GetSimTime(), branches[0].Fork, same.GetSimTime())
+	}
+	differs := false
+	for p := 0; p < M.Points; p++ {
+		_, sum, _ := S.GetStatistic(p)
+		count, _ := S.GetCount(p)
+		_, branchSum, _ := same.GetStatistic(p)
+		branchCount, _ := same.GetCount(p)
+		if sum != branchSum || count != branchCount {
+			t.Errorf("Expected statistic %.2f of %d values of point %d, got %.2f of %d", sum, count, p, branchSum, branchCount)
+		}
+		_, closedSum, _ := closed.GetStatistic(p)
+		differs = differs || closedSum != sum
+	}
+	if !differs || branches[1].Waiting(M.Stations[0]) <= branches[0].Waiting(M.Stations[0]) {
+		t.Errorf("Expected closure of section AC to delay trains at A, got waiting %.2f and %.2f",
+			branches[0].Waiting(M.Stations[0]), branches[1].Waiting(M.Stations[0]))
+	}
+}